@@ -4,18 +4,25 @@ go 1.22.1
 
 replace github.com/TheRangiCrew/NEXRAD-GO/server => ./server
 
+replace github.com/TheRangiCrew/NEXRAD-GO/level2 => ./level2
+
+replace github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad => ./level2/nexrad
+
+replace github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr => ./level2/tdwr
+
 require (
 	github.com/TheRangiCrew/NEXRAD-GO/server v0.0.0-00010101000000-000000000000
 	github.com/aws/aws-sdk-go-v2/config v1.27.13 // indirect
 )
 
 require (
-	github.com/TheRangiCrew/NEXRAD-GO/level2 v0.0.0-20240419005628-d98dda7ac56e // indirect
+	github.com/TheRangiCrew/NEXRAD-GO/level2 v0.0.0-20240419005628-d98dda7ac56e
 	github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad v0.0.0-20240422075631-1de2515c31c9 // indirect
 	github.com/paulmach/go.geojson v1.5.0 // indirect
 )
 
 require (
+	github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr v0.0.0-00010101000000-000000000000 // indirect
 	github.com/aws/aws-sdk-go-v2 v1.26.1 // indirect
 	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.6.2 // indirect
 	github.com/aws/aws-sdk-go-v2/credentials v1.17.13 // indirect
@@ -35,6 +42,8 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.24.0 // indirect
 	github.com/aws/aws-sdk-go-v2/service/sts v1.28.7 // indirect
 	github.com/aws/smithy-go v1.20.2 // indirect
+	github.com/dsnet/compress v0.0.1 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/surrealdb/surrealdb.go v0.2.2-0.20240205063555-7c2584a964ab // indirect
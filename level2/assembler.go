@@ -0,0 +1,272 @@
+package level2
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VolumeKey identifies a volume of the real-time feed. The chunks of a volume share all three
+type VolumeKey struct {
+	Site     string
+	VolumeID int
+	InitTime time.Time
+}
+
+type AssemblyEventType uint8
+
+const (
+	SweepComplete  AssemblyEventType = iota // A radial ending an elevation has been handed on in order
+	ChunksMissing                           // Chunks were given up on, the volume will be missing them
+	VolumeComplete                          // The E chunk and every chunk before it have been handled
+)
+
+type AssemblyEvent struct {
+	Type           AssemblyEventType
+	Key            VolumeKey
+	Elevation      int     // Elevation number of the completed sweep
+	ElevationAngle float32 // degrees
+	Missing        []int   // Numbers of the chunks given up on, for ChunksMissing and VolumeComplete
+	Volume         []byte  // The volume as an AR2V file for VolumeComplete, without any missing chunks
+}
+
+/*
+Assembler rebuilds volumes from the chunks of the real-time feed, which can arrive out of order or not
+at all. Chunks are handed on in order of their number. A chunk is given up on once MaxPending later
+chunks of its volume are waiting for it, or when the next volume of the site starts. Volumes are
+forgotten MaxAge after their last chunk in case their E chunk never comes. It is safe to add chunks
+from many goroutines
+*/
+type Assembler struct {
+	Limits     Limits
+	MaxPending int           // 5 if not set
+	MaxAge     time.Duration // 30 minutes if not set
+	EventsOnly bool          // Chunks are not kept and VolumeComplete has no Volume, for callers that only want the events
+
+	lock    sync.Mutex
+	volumes map[VolumeKey]*assembly
+}
+
+// A volume being put together
+type assembly struct {
+	next     int            // Number of the next chunk to hand on
+	pending  map[int][]byte // Chunks that came before the ones in front of them
+	end      int            // Number of the E chunk, 0 until it comes
+	missing  []int
+	volume   bytes.Buffer
+	lastSeen time.Time
+}
+
+func NewAssembler() *Assembler {
+	return &Assembler{
+		Limits:  DefaultLimits,
+		volumes: make(map[VolumeKey]*assembly),
+	}
+}
+
+/*
+Add adds a chunk and returns what happened because of it, in order. Chunks that were already handed
+on are ignored. An error means the chunk could not be read, it is still part of the volume
+*/
+func (a *Assembler) Add(chunk ChunkFileData, data []byte) ([]AssemblyEvent, error) {
+	if chunk.Number < 1 {
+		return nil, fmt.Errorf("invalid chunk number %d", chunk.Number)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.volumes == nil {
+		a.volumes = make(map[VolumeKey]*assembly)
+	}
+
+	key := chunk.Key()
+	now := time.Now()
+	events := a.expire(key, now)
+
+	asm, ok := a.volumes[key]
+	if !ok {
+		asm = &assembly{next: 1, pending: make(map[int][]byte)}
+		a.volumes[key] = asm
+	}
+	asm.lastSeen = now
+
+	if _, ok := asm.pending[chunk.Number]; ok || chunk.Number < asm.next {
+		return events, nil
+	}
+	if chunk.ChunkType == ChunkEnd {
+		asm.end = chunk.Number
+	}
+	asm.pending[chunk.Number] = data
+
+	maxPending := a.MaxPending
+	if maxPending <= 0 {
+		maxPending = 5
+	}
+
+	var err error
+	for {
+		data, ok := asm.pending[asm.next]
+		if ok {
+			delete(asm.pending, asm.next)
+			handled, chunkErr := a.handle(key, asm, data)
+			events = append(events, handled...)
+			if chunkErr != nil && err == nil {
+				err = chunkErr
+			}
+		} else if len(asm.pending) > maxPending {
+			asm.missing = append(asm.missing, asm.next)
+			events = append(events, AssemblyEvent{Type: ChunksMissing, Key: key, Missing: []int{asm.next}})
+		} else {
+			break
+		}
+		asm.next++
+	}
+
+	if asm.end != 0 && asm.next > asm.end {
+		events = append(events, asm.complete(key))
+		delete(a.volumes, key)
+	}
+
+	return events, err
+}
+
+// Appends a chunk to the volume, returning the sweeps that it ends
+func (a *Assembler) handle(key VolumeKey, asm *assembly, data []byte) ([]AssemblyEvent, error) {
+	if !a.EventsOnly {
+		asm.volume.Write(data)
+	}
+
+	events := []AssemblyEvent{}
+	err := chunkRadials(data, a.Limits, func(header *Message31Header) {
+		status := RadialStatus(header.RadialStatus)
+		if status == EndOfElevation || status == EndOfVolume {
+			events = append(events, AssemblyEvent{
+				Type:           SweepComplete,
+				Key:            key,
+				Elevation:      int(header.ElevationNumber),
+				ElevationAngle: header.ElevationAngle,
+			})
+		}
+	})
+	return events, err
+}
+
+func (asm *assembly) complete(key VolumeKey) AssemblyEvent {
+	return AssemblyEvent{
+		Type:    VolumeComplete,
+		Key:     key,
+		Missing: asm.missing,
+		Volume:  asm.volume.Bytes(),
+	}
+}
+
+/*
+Gives up on volumes that have not had a chunk for MaxAge and on the earlier volumes of the site of
+key, handing on what they have. A volume whose E chunk came is still returned as complete
+*/
+func (a *Assembler) expire(key VolumeKey, now time.Time) []AssemblyEvent {
+	maxAge := a.MaxAge
+	if maxAge <= 0 {
+		maxAge = 30 * time.Minute
+	}
+
+	keys := []VolumeKey{}
+	for k, asm := range a.volumes {
+		earlier := k.Site == key.Site && k.InitTime.Before(key.InitTime)
+		if earlier || now.Sub(asm.lastSeen) > maxAge {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].InitTime.Before(keys[j].InitTime)
+	})
+
+	events := []AssemblyEvent{}
+	for _, k := range keys {
+		asm := a.volumes[k]
+		delete(a.volumes, k)
+
+		// Hand on what came, skipping the holes
+		numbers := make([]int, 0, len(asm.pending))
+		for n := range asm.pending {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+
+		missing := []int{}
+		for _, n := range numbers {
+			for ; asm.next < n; asm.next++ {
+				missing = append(missing, asm.next)
+			}
+			handled, _ := a.handle(k, asm, asm.pending[n])
+			events = append(events, handled...)
+			asm.next++
+		}
+		// Without the E chunk it is not known how many chunks are missing after the last one
+		for ; asm.next <= asm.end; asm.next++ {
+			missing = append(missing, asm.next)
+		}
+
+		if len(missing) > 0 {
+			asm.missing = append(asm.missing, missing...)
+			events = append(events, AssemblyEvent{Type: ChunksMissing, Key: k, Missing: missing})
+		}
+		if asm.end != 0 {
+			events = append(events, asm.complete(k))
+		}
+	}
+
+	return events
+}
+
+// Calls fn with the header of every Message 31 radial in the LDM records of a chunk
+func chunkRadials(data []byte, limits Limits, fn func(header *Message31Header)) error {
+	file := bytes.NewReader(data)
+	if len(data) >= FileHeaderSize && (IsNexradTape(string(data[:9])) || IsTDWRTape(string(data[:9]))) {
+		file.Seek(FileHeaderSize, io.SeekStart)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for record := range DecompressRecords(ctx, file, limits, 1) {
+		if record.Err != nil {
+			return record.Err
+		}
+
+		for {
+			if _, err := record.Data.Seek(CTMHeaderSize, io.SeekCurrent); err != nil {
+				break
+			}
+			messageHeader := MessageHeader{}
+			if err := binary.Read(record.Data, binary.BigEndian, &messageHeader); err != nil {
+				break
+			}
+
+			if messageHeader.MessageType != 31 {
+				record.Data.Seek(MessageBodySize, io.SeekCurrent)
+				continue
+			}
+
+			header := Message31Header{}
+			if err := binary.Read(record.Data, binary.BigEndian, &header); err != nil {
+				return NewError(ErrTruncatedRecord, record.Offset, err)
+			}
+			fn(&header)
+
+			size := int64(messageHeader.Size)*2 - MessageHeaderSize - int64(binary.Size(header))
+			if size < 0 {
+				return NewError(ErrCorruptMessage, record.Offset, fmt.Errorf("invalid message size %d", messageHeader.Size))
+			}
+			record.Data.Seek(size, io.SeekCurrent)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package level2
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testChunk struct {
+	ChunkFileData
+	data []byte
+}
+
+// Reads the KHDX chunks in test/chunks with the last one made the E chunk, skipping the test when they are not there
+func khdxChunks(t *testing.T) []testChunk {
+	t.Helper()
+
+	names, _ := filepath.Glob("../test/chunks/20240401-214657-*")
+	if len(names) == 0 {
+		t.Skip("no chunks in test/chunks")
+	}
+	sort.Strings(names)
+
+	chunks := []testChunk{}
+	for _, name := range names {
+		chunkData, err := FilenameToChunkData("KHDX/585/" + filepath.Base(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, testChunk{*chunkData, data})
+	}
+	chunks[len(chunks)-1].ChunkType = ChunkEnd
+	return chunks
+}
+
+// Adds the chunks in the given order, returning every event
+func addChunks(t *testing.T, a *Assembler, chunks []testChunk, order []int) []AssemblyEvent {
+	t.Helper()
+
+	events := []AssemblyEvent{}
+	for _, i := range order {
+		e, err := a.Add(chunks[i].ChunkFileData, chunks[i].data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e...)
+	}
+	return events
+}
+
+func eventsOf(events []AssemblyEvent, eventType AssemblyEventType) []AssemblyEvent {
+	found := []AssemblyEvent{}
+	for _, e := range events {
+		if e.Type == eventType {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func TestFilenameToChunkData(t *testing.T) {
+	c, err := FilenameToChunkData("KHDX/585/20240401-214657-003-I")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ChunkFileData{"KHDX", 585, time.Date(2024, 4, 1, 21, 46, 57, 0, time.UTC), 3, ChunkIntermediate}
+	if *c != want {
+		t.Errorf("got %+v, want %+v", *c, want)
+	}
+	if c.Filename() != "20240401-214657-003-I" {
+		t.Errorf("filename %s", c.Filename())
+	}
+	if c.Path() != "KHDX/585/20240401-214657-003-I" {
+		t.Errorf("path %s", c.Path())
+	}
+
+	for _, name := range []string{"20240401-214657-003", "20240401-2146-003-I", "20240401-214657-x-I"} {
+		if _, err := FilenameToChunkData(name); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestSplitArchive(t *testing.T) {
+	chunks := khdxChunks(t)
+
+	archive := []byte{}
+	for _, c := range chunks {
+		archive = append(archive, c.data...)
+	}
+
+	split, err := SplitArchive(bytes.NewReader(archive), 585)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != len(chunks) {
+		t.Fatalf("%d chunks, want %d", len(split), len(chunks))
+	}
+	for i, c := range split {
+		if c.ChunkFileData != chunks[i].ChunkFileData {
+			t.Errorf("chunk %d is %+v, want %+v", i+1, c.ChunkFileData, chunks[i].ChunkFileData)
+		}
+		if !bytes.Equal(c.Data, chunks[i].data) {
+			t.Errorf("chunk %d does not match the feed", i+1)
+		}
+	}
+
+	// Chunks without a volume header are not archives
+	if _, err := SplitArchive(bytes.NewReader(chunks[1].data), 585); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("split a chunk: %v", err)
+	}
+	if _, err := SplitArchive(bytes.NewReader(chunks[0].data), 585); !errors.Is(err, ErrTruncatedRecord) {
+		t.Errorf("split an archive without radials: %v", err)
+	}
+}
+
+func TestLastRadialTime(t *testing.T) {
+	chunks := khdxChunks(t)
+
+	previous := time.Time{}
+	for i, c := range chunks {
+		last, err := LastRadialTime(c.data, DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if !last.IsZero() {
+				t.Errorf("S chunk has radials at %s", last)
+			}
+			previous = c.InitTime
+			continue
+		}
+		if last.Before(previous) || last.Sub(c.InitTime) > 10*time.Minute {
+			t.Errorf("chunk %d sent at %s after %s", c.Number, last, previous)
+		}
+		previous = last
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	chunks := khdxChunks(t)
+
+	inOrder := make([]int, len(chunks))
+	for i := range inOrder {
+		inOrder[i] = i
+	}
+	// Swaps neighbouring chunks and sends some twice
+	shuffled := []int{1, 0, 3, 2, 2, 4, 6, 5, 0}
+	for i := 7; i < len(chunks); i++ {
+		shuffled = append(shuffled, i)
+	}
+
+	want := []byte{}
+	for _, c := range chunks {
+		want = append(want, c.data...)
+	}
+
+	for name, order := range map[string][]int{"in order": inOrder, "shuffled": shuffled} {
+		events := addChunks(t, NewAssembler(), chunks, order)
+
+		sweeps := eventsOf(events, SweepComplete)
+		if len(sweeps) != 2 || sweeps[0].Elevation != 1 || sweeps[1].Elevation != 2 {
+			t.Errorf("%s: sweep events %+v", name, sweeps)
+		}
+		if len(eventsOf(events, ChunksMissing)) != 0 {
+			t.Errorf("%s: chunks missing", name)
+		}
+
+		volumes := eventsOf(events, VolumeComplete)
+		if len(volumes) != 1 {
+			t.Fatalf("%s: %d volumes", name, len(volumes))
+		}
+		if volumes[0].Key != chunks[0].Key() || !bytes.Equal(volumes[0].Volume, want) {
+			t.Errorf("%s: volume is not the chunks in order", name)
+		}
+		if format, err := DetectFormat(bytes.NewReader(volumes[0].Volume)); err != nil || !format.HasVolumeHeader {
+			t.Errorf("%s: volume has no volume header", name)
+		}
+	}
+}
+
+func TestAssemblerGaps(t *testing.T) {
+	chunks := khdxChunks(t)
+
+	// Chunk 4 never comes
+	order := []int{0, 1, 2}
+	for i := 4; i < len(chunks); i++ {
+		order = append(order, i)
+	}
+	a := NewAssembler()
+	a.MaxPending = 3
+	events := addChunks(t, a, chunks, order)
+
+	missing := eventsOf(events, ChunksMissing)
+	if len(missing) != 1 || len(missing[0].Missing) != 1 || missing[0].Missing[0] != 4 {
+		t.Fatalf("missing events %+v", missing)
+	}
+	// Missing must be reported before the sweep ended by a later chunk
+	if events[0].Type != ChunksMissing {
+		t.Errorf("first event %+v", events[0])
+	}
+	volumes := eventsOf(events, VolumeComplete)
+	if len(volumes) != 1 || len(volumes[0].Missing) != 1 {
+		t.Fatalf("volume events %+v", volumes)
+	}
+
+	// The E chunk is lost, so the volume is handed on when the next one starts
+	a = NewAssembler()
+	a.MaxPending = 10
+	events = addChunks(t, a, chunks, inOrderWithout(len(chunks), 9, len(chunks)-1))
+	if len(eventsOf(events, VolumeComplete)) != 0 {
+		t.Fatal("volume completed without its E chunk")
+	}
+	next := chunks[0]
+	next.InitTime = next.InitTime.Add(5 * time.Minute)
+	events, _ = a.Add(next.ChunkFileData, next.data)
+	missing = eventsOf(events, ChunksMissing)
+	if len(missing) != 1 || missing[0].Missing[0] != 10 || missing[0].Key != chunks[0].Key() {
+		t.Errorf("missing events %+v", missing)
+	}
+	if len(eventsOf(events, SweepComplete)) != 1 {
+		t.Errorf("sweeps after the hole were not handed on")
+	}
+}
+
+// The chunk indexes up to n, leaving out those given
+func inOrderWithout(n int, without ...int) []int {
+	order := []int{}
+	for i := 0; i < n; i++ {
+		skip := false
+		for _, w := range without {
+			skip = skip || i == w
+		}
+		if !skip {
+			order = append(order, i)
+		}
+	}
+	return order
+}
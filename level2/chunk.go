@@ -0,0 +1,180 @@
+package level2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chunk types of the real-time feed
+const (
+	ChunkStart        = "S" // Volume header and metadata
+	ChunkIntermediate = "I"
+	ChunkEnd          = "E" // Holds the last radial of the volume
+)
+
+// ChunkFileData describes a chunk of the real-time feed, from its name or the notification that announced it
+type ChunkFileData struct {
+	Site      string
+	VolumeID  int       // 1 to 999 as numbered by the feed, 0 if not known
+	InitTime  time.Time // Start of the volume, the same for all of its chunks
+	Number    int       // 1 for the S chunk
+	ChunkType string
+}
+
+// The layout of the time in chunk names
+const chunkTimeLayout = "20060102-150405"
+
+/*
+FilenameToChunkData parses the name of a chunk, e.g. "20240401-214657-001-S". The site and volume ID
+are also read from the full key of the chunk in the bucket, e.g. "KHDX/585/20240401-214657-001-S"
+*/
+func FilenameToChunkData(filename string) (*ChunkFileData, error) {
+	chunkData := ChunkFileData{}
+
+	dir, name := path.Split(filename)
+	if dir != "" {
+		parts := strings.Split(strings.Trim(dir, "/"), "/")
+		if len(parts) >= 2 {
+			chunkData.Site = parts[len(parts)-2]
+			chunkData.VolumeID, _ = strconv.Atoi(parts[len(parts)-1])
+		}
+	}
+
+	segments := strings.Split(name, "-")
+	if len(segments) != 4 {
+		return nil, fmt.Errorf("%s is not a chunk name", name)
+	}
+
+	t, err := time.Parse(chunkTimeLayout, segments[0]+"-"+segments[1])
+	if err != nil {
+		return nil, err
+	}
+	chunkData.InitTime = t
+
+	chunkData.Number, err = strconv.Atoi(segments[2])
+	if err != nil {
+		return nil, err
+	}
+
+	chunkData.ChunkType = segments[3]
+
+	return &chunkData, nil
+}
+
+// Filename returns the name of the chunk as the feed names it
+func (c ChunkFileData) Filename() string {
+	return fmt.Sprintf("%s-%03d-%s", c.InitTime.UTC().Format(chunkTimeLayout), c.Number, c.ChunkType)
+}
+
+// Path returns the full key of the chunk in the bucket, e.g. "KHDX/585/20240401-214657-001-S"
+func (c ChunkFileData) Path() string {
+	return path.Join(c.Site, strconv.Itoa(c.VolumeID), c.Filename())
+}
+
+// Key returns the volume the chunk belongs to
+func (c ChunkFileData) Key() VolumeKey {
+	return VolumeKey{
+		Site:     c.Site,
+		VolumeID: c.VolumeID,
+		InitTime: c.InitTime,
+	}
+}
+
+/*
+LastRadialTime returns when the last radial of a chunk was collected, which is about when the feed
+sends the chunk. It is zero for chunks without radials such as the S chunk
+*/
+func LastRadialTime(data []byte, limits Limits) (time.Time, error) {
+	last := time.Time{}
+	err := chunkRadials(data, limits, func(header *Message31Header) {
+		last = JulianDateToTime(uint32(header.CollectionDate), header.CollectionTime)
+	})
+	return last, err
+}
+
+// Chunk is a chunk of the real-time feed with its contents
+type Chunk struct {
+	ChunkFileData
+	Data []byte
+}
+
+/*
+SplitArchive splits a compressed archive into the chunks the real-time feed sends it as: an S chunk
+holding the volume header and the metadata record, then an I chunk for each LDM record of radials
+with the last one being the E chunk. The feed numbers its volumes but archives don't keep it, so
+volumeID is given. Uncompressed and legacy archives can be written with nexrad.Encode first
+*/
+func SplitArchive(file io.ReadSeeker, volumeID int) ([]Chunk, error) {
+	format, err := DetectFormat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !format.HasVolumeHeader || !format.Compressed {
+		return nil, NewError(ErrUnknownFormat, 0, fmt.Errorf("only compressed archives with a volume header can be split"))
+	}
+
+	// The volume header is kept as it is for the S chunk
+	start := make([]byte, FileHeaderSize)
+	if _, err := io.ReadFull(file, start); err != nil {
+		return nil, NewError(ErrTruncatedRecord, 0, err)
+	}
+	header, err := GetVolumeHeader(bytes.NewReader(start))
+	if err != nil {
+		return nil, err
+	}
+
+	chunkData := ChunkFileData{
+		Site:      strings.TrimSpace(string(header.ICAO[:])),
+		VolumeID:  volumeID,
+		InitTime:  header.Date().Truncate(time.Second),
+		Number:    1,
+		ChunkType: ChunkStart,
+	}
+
+	chunks := []Chunk{}
+	for {
+		offset, _ := file.Seek(0, io.SeekCurrent)
+
+		var size int32
+		if err := binary.Read(file, binary.BigEndian, &size); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, NewError(ErrTruncatedRecord, offset, err)
+		}
+		n := int64(size)
+		if n < 0 {
+			n = -n
+		}
+		if n > DefaultLimits.MaxRecordSize {
+			return nil, NewError(ErrSizeLimit, offset, nil)
+		}
+
+		record := make([]byte, 4+n)
+		binary.BigEndian.PutUint32(record, uint32(size))
+		if _, err := io.ReadFull(file, record[4:]); err != nil {
+			return nil, NewError(ErrTruncatedRecord, offset, err)
+		}
+
+		if len(chunks) == 0 {
+			record = append(start, record...)
+		}
+		chunks = append(chunks, Chunk{chunkData, record})
+
+		chunkData.Number++
+		chunkData.ChunkType = ChunkIntermediate
+	}
+
+	if len(chunks) < 2 {
+		return nil, NewError(ErrTruncatedRecord, FileHeaderSize, fmt.Errorf("archive has no radials"))
+	}
+	chunks[len(chunks)-1].ChunkType = ChunkEnd
+
+	return chunks, nil
+}
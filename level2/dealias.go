@@ -0,0 +1,368 @@
+package level2
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"math"
+)
+
+// Name of the moment Dealias adds
+const DealiasedVelocity = "DVEL"
+
+// How dealiased velocity is stored, to 0.01 m/s
+const (
+	dealiasScale  = 100
+	dealiasOffset = 32768
+)
+
+/*
+Neighbouring gates whose velocities differ by more than this fraction of the Nyquist velocity are
+put in different regions. A fold between them is a jump of close to twice the Nyquist velocity
+*/
+const regionThreshold = 0.5
+
+// Fewest gates of a region that must line up with the previous volume for its fold to be taken from it
+const minPreviousGates = 5
+
+/*
+Dealias unfolds the velocity of every sweep, adding it to the rays as DealiasedVelocity. Sweeps are
+checked against the sweep at the same angle in previous when it is given, see Sweep.Dealias. Sweeps
+from MergeSplitCuts only have the dealiased velocity if the volume was dealiased first
+*/
+func (v *Volume) Dealias(previous *Volume) {
+	for _, sweep := range v.Sweeps {
+		var reference *Sweep
+		if previous != nil {
+			for _, s := range previous.Sweeps {
+				if _, ok := s.Geometry(DealiasedVelocity); ok && sameAngle(s.ElevationAngle, sweep.ElevationAngle) {
+					reference = s
+					break
+				}
+			}
+		}
+		sweep.Dealias(reference)
+	}
+}
+
+/*
+Dealias unfolds the VEL moment of each ray and adds it to the ray as DealiasedVelocity, in m/s. The
+sweep is split into regions of gates whose velocities run smoothly into one another, so that folds
+lie on the edges between regions. Each region is then moved by the multiple of twice the Nyquist
+velocity that best matches the regions around it, starting from the largest. When previous is the
+dealiased sweep at the same angle from the volume before, regions that overlap it take their fold
+from it instead, which fixes sweeps where the largest region is folded. The rays are changed, so
+sweeps sharing them see the dealiased velocity too. It returns false if no ray has VEL
+*/
+func (s *Sweep) Dealias(previous *Sweep) bool {
+	d := newDealiaser(s.Sorted())
+	if len(d.rays) == 0 {
+		return false
+	}
+
+	d.label()
+	if previous != nil {
+		d.seedFrom(previous.Sorted())
+	}
+	d.unfold()
+
+	for i, ray := range d.rays {
+		vel := ray.Moments["VEL"]
+		values := make([]float32, len(d.velocity[i]))
+		for g, v := range d.velocity[i] {
+			values[g] = float32(math.NaN())
+			if l := d.labels[i][g]; l >= 0 {
+				values[g] = float32(v + 2*float64(d.folds[l])*d.nyquist[i])
+			}
+		}
+
+		gates := EncodeGates(values, dealiasScale, dealiasOffset)
+		for g := range values {
+			if vel.Mask(g) == GateRangeFolded {
+				binary.BigEndian.PutUint16(gates.raw[g*2:], 1)
+			}
+		}
+
+		ray.Moments[DealiasedVelocity] = &Moment{
+			Name:         DealiasedVelocity,
+			FirstGate:    vel.FirstGate,
+			GateInterval: vel.GateInterval,
+			Gates:        gates,
+		}
+	}
+	return true
+}
+
+type dealiaser struct {
+	rays     []*Ray      // The rays with VEL in order of azimuth
+	velocity [][]float64 // VEL of each gate, NaN without data
+	nyquist  []float64   // m/s
+	wrap     bool        // The last ray is next to the first
+
+	labels        [][]int32 // Region of each gate, -1 without data
+	sizes         []int
+	regionNyquist []float64               // m/s, the same across a sweep
+	edges         []map[int32]*regionEdge // The regions next to each region
+	folds         []int
+	settled       []bool
+}
+
+// The gates along the edge between two regions
+type regionEdge struct {
+	count int
+	sum   float64 // Velocity of the region the edge belongs to less that of its neighbour, summed over the gates
+}
+
+func newDealiaser(sweep *Sweep) *dealiaser {
+	d := dealiaser{}
+	for _, ray := range sweep.Rays {
+		vel, ok := ray.Moments["VEL"]
+		if !ok || ray.NyquistVelocity <= 0 {
+			continue
+		}
+		velocity := make([]float64, vel.Len())
+		for g := range velocity {
+			velocity[g] = float64(vel.Value(g))
+		}
+		d.rays = append(d.rays, ray)
+		d.velocity = append(d.velocity, velocity)
+		d.nyquist = append(d.nyquist, float64(ray.NyquistVelocity))
+	}
+
+	// Sector scans don't come back round to where they started
+	if n := len(d.rays); n > 2 {
+		gap := azimuthDistance(d.rays[0].Azimuth, d.rays[n-1].Azimuth)
+		d.wrap = gap <= 2*azimuthDistance(d.rays[0].Azimuth, d.rays[1].Azimuth)
+	}
+	return &d
+}
+
+// Calls fn with each pair of neighbouring gates that both have data, once per pair
+func (d *dealiaser) neighbours(fn func(r1, g1, r2, g2 int)) {
+	for r := range d.rays {
+		next := r + 1
+		if next == len(d.rays) {
+			if !d.wrap {
+				next = -1
+			} else {
+				next = 0
+			}
+		}
+
+		for g, v := range d.velocity[r] {
+			if math.IsNaN(v) {
+				continue
+			}
+			if g+1 < len(d.velocity[r]) && !math.IsNaN(d.velocity[r][g+1]) {
+				fn(r, g, r, g+1)
+			}
+			if next >= 0 && g < len(d.velocity[next]) && !math.IsNaN(d.velocity[next][g]) {
+				fn(r, g, next, g)
+			}
+		}
+	}
+}
+
+// Splits the gates into regions that have no folds inside them and finds the edges between them
+func (d *dealiaser) label() {
+	// Union find over the gates, numbered along each ray in turn
+	offsets := make([]int, len(d.rays)+1)
+	for r := range d.rays {
+		offsets[r+1] = offsets[r] + len(d.velocity[r])
+	}
+	parent := make([]int, offsets[len(d.rays)])
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	d.neighbours(func(r1, g1, r2, g2 int) {
+		threshold := regionThreshold * math.Min(d.nyquist[r1], d.nyquist[r2])
+		if math.Abs(d.velocity[r1][g1]-d.velocity[r2][g2]) < threshold {
+			parent[find(offsets[r1]+g1)] = find(offsets[r2] + g2)
+		}
+	})
+
+	roots := map[int]int32{}
+	d.labels = make([][]int32, len(d.rays))
+	for r := range d.rays {
+		d.labels[r] = make([]int32, len(d.velocity[r]))
+		for g, v := range d.velocity[r] {
+			if math.IsNaN(v) {
+				d.labels[r][g] = -1
+				continue
+			}
+			root := find(offsets[r] + g)
+			label, ok := roots[root]
+			if !ok {
+				label = int32(len(d.sizes))
+				roots[root] = label
+				d.sizes = append(d.sizes, 0)
+				d.regionNyquist = append(d.regionNyquist, d.nyquist[r])
+				d.edges = append(d.edges, map[int32]*regionEdge{})
+			}
+			d.labels[r][g] = label
+			d.sizes[label]++
+		}
+	}
+	d.folds = make([]int, len(d.sizes))
+	d.settled = make([]bool, len(d.sizes))
+
+	d.neighbours(func(r1, g1, r2, g2 int) {
+		a, b := d.labels[r1][g1], d.labels[r2][g2]
+		if a == b {
+			return
+		}
+		diff := d.velocity[r1][g1] - d.velocity[r2][g2]
+		d.edge(a, b).count++
+		d.edge(a, b).sum += diff
+		d.edge(b, a).count++
+		d.edge(b, a).sum -= diff
+	})
+}
+
+func (d *dealiaser) edge(from int32, to int32) *regionEdge {
+	e, ok := d.edges[from][to]
+	if !ok {
+		e = &regionEdge{}
+		d.edges[from][to] = e
+	}
+	return e
+}
+
+// Settles the folds of the regions that overlap the dealiased velocity of the previous volume
+func (d *dealiaser) seedFrom(previous *Sweep) {
+	votes := make([]map[int]int, len(d.sizes))
+	for r, ray := range d.rays {
+		near := previous.Nearest(ray.Azimuth)
+		if near == nil || azimuthDistance(near.Azimuth, ray.Azimuth) > 2 {
+			continue
+		}
+		reference, ok := near.Moments[DealiasedVelocity]
+		if !ok || reference.GateInterval <= 0 {
+			continue
+		}
+		vel := ray.Moments["VEL"]
+
+		for g, v := range d.velocity[r] {
+			label := d.labels[r][g]
+			if label < 0 {
+				continue
+			}
+			j := int(math.Round(float64((vel.FirstGate + float32(g)*vel.GateInterval - reference.FirstGate) / reference.GateInterval)))
+			if j < 0 || j >= reference.Len() {
+				continue
+			}
+			want := float64(reference.Value(j))
+			if math.IsNaN(want) {
+				continue
+			}
+
+			if votes[label] == nil {
+				votes[label] = map[int]int{}
+			}
+			votes[label][int(math.Round((want-v)/(2*d.nyquist[r])))]++
+		}
+	}
+
+	// A region is only settled when most of it agrees on the fold
+	for label, counts := range votes {
+		total, best, fold := 0, 0, 0
+		for k, n := range counts {
+			total += n
+			if n > best {
+				best, fold = n, k
+			}
+		}
+		if best >= minPreviousGates && 3*best >= 2*total {
+			d.folds[label] = fold
+			d.settled[label] = true
+		}
+	}
+}
+
+/*
+Settles the folds of the rest of the regions, each time taking the region with the longest edge
+with those already settled. Regions cut off from any settled region start again from the largest
+of them, which is taken to be unfolded
+*/
+func (d *dealiaser) unfold() {
+	// Length of each unsettled region's edge with the settled regions
+	settledEdge := make([]int, len(d.sizes))
+	queue := &edgeQueue{}
+
+	settle := func(label int32) {
+		d.settled[label] = true
+		for neighbour, e := range d.edges[label] {
+			if d.settled[neighbour] {
+				continue
+			}
+			settledEdge[neighbour] += e.count
+			heap.Push(queue, queuedRegion{neighbour, settledEdge[neighbour]})
+		}
+	}
+
+	for label := range d.sizes {
+		if d.settled[label] {
+			settle(int32(label))
+		}
+	}
+
+	for {
+		if queue.Len() == 0 {
+			largest := int32(-1)
+			for label, size := range d.sizes {
+				if !d.settled[label] && (largest < 0 || size > d.sizes[largest]) {
+					largest = int32(label)
+				}
+			}
+			if largest < 0 {
+				return
+			}
+			d.folds[largest] = 0
+			settle(largest)
+			continue
+		}
+
+		next := heap.Pop(queue).(queuedRegion)
+		if d.settled[next.label] || next.edge != settledEdge[next.label] {
+			continue
+		}
+
+		// The fold that brings the region closest to its settled neighbours across their edges
+		sum, count, nyquist := 0.0, 0, d.regionNyquist[next.label]
+		for neighbour, e := range d.edges[next.label] {
+			if !d.settled[neighbour] {
+				continue
+			}
+			sum += e.sum - float64(e.count)*2*float64(d.folds[neighbour])*nyquist
+			count += e.count
+		}
+		d.folds[next.label] = int(math.Round(-sum / float64(count) / (2 * nyquist)))
+		settle(next.label)
+	}
+}
+
+type queuedRegion struct {
+	label int32
+	edge  int
+}
+
+// A max heap of regions by the length of their edge with settled regions
+type edgeQueue []queuedRegion
+
+func (q edgeQueue) Len() int           { return len(q) }
+func (q edgeQueue) Less(i, j int) bool { return q[i].edge > q[j].edge }
+func (q edgeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *edgeQueue) Push(x any)        { *q = append(*q, x.(queuedRegion)) }
+func (q *edgeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
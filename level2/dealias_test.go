@@ -0,0 +1,93 @@
+package level2
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// Folds a velocity into the Nyquist interval as the radar does
+func fold(v float64, nyquist float64) float32 {
+	return float32(v - 2*nyquist*math.Round(v/(2*nyquist)))
+}
+
+// A sweep of one degree rays with 200 gates of 0.25 km. velocity gives the true velocity of each gate
+func velocitySweep(nyquist float64, velocity func(azimuth float64, rng float64) float64) *Sweep {
+	sweep := testSweep(1, 0.5, 90, 360)
+	for _, ray := range sweep.Rays {
+		ray.NyquistVelocity = float32(nyquist)
+		values := make([]float32, 200)
+		for g := range values {
+			values[g] = fold(velocity(float64(ray.Azimuth), 2+0.25*float64(g)), nyquist)
+		}
+		ray.Moments["VEL"] = &Moment{Name: "VEL", FirstGate: 2, GateInterval: 0.25, Gates: EncodeGates(values, 2, 129)}
+	}
+	return sweep
+}
+
+// Checks the dealiased velocity of every gate against the truth
+func checkDealiased(t *testing.T, sweep *Sweep, velocity func(azimuth float64, rng float64) float64) {
+	t.Helper()
+	wrong := 0
+	for _, ray := range sweep.Rays {
+		dvel := ray.Moments[DealiasedVelocity]
+		for g := 0; g < dvel.Len(); g++ {
+			want := velocity(float64(ray.Azimuth), 2+0.25*float64(g))
+			if dvel.Mask(g) != GateValid || math.Abs(float64(dvel.Value(g))-want) > 0.5 {
+				wrong++
+			}
+		}
+	}
+	if wrong > 0 {
+		t.Errorf("%d gates dealiased wrongly", wrong)
+	}
+}
+
+func TestDealias(t *testing.T) {
+	// Winds of up to 30 m/s that veer with range, folding more than once at a Nyquist of 10
+	wind := func(azimuth float64, rng float64) float64 {
+		return 30 * math.Sin((azimuth-rng)*math.Pi/180) * math.Min(1, rng/20)
+	}
+	sweep := velocitySweep(10, wind)
+	if !sweep.Dealias(nil) {
+		t.Fatal("nothing dealiased")
+	}
+	checkDealiased(t, sweep, wind)
+
+	// Range folded gates stay range folded
+	sweep = velocitySweep(10, wind)
+	binary.BigEndian.PutUint16(sweep.Rays[5].Moments["VEL"].Raw()[10:], 1)
+	sweep.Dealias(nil)
+	if mask := sweep.Rays[5].Moments[DealiasedVelocity].Mask(5); mask != GateRangeFolded {
+		t.Errorf("range folded gate is %v", mask)
+	}
+
+	if (&Sweep{Rays: []*Ray{{Moments: map[string]*Moment{}}}}).Dealias(nil) {
+		t.Error("dealiased a sweep without VEL")
+	}
+}
+
+func TestDealiasPrevious(t *testing.T) {
+	// A 15 m/s wind folds everywhere at a Nyquist of 10, so the sweep alone can't tell
+	wind := func(azimuth float64, rng float64) float64 {
+		return 15 + 3*math.Cos(azimuth*math.Pi/180)
+	}
+	sweep := velocitySweep(10, wind)
+	sweep.Dealias(nil)
+	if v := sweep.Rays[0].Moments[DealiasedVelocity].Value(0); v > 0 {
+		t.Fatalf("dealiased to %v without the previous volume", v)
+	}
+
+	previous := velocitySweep(10, wind)
+	for _, ray := range previous.Rays {
+		values := make([]float32, 200)
+		for g := range values {
+			values[g] = float32(wind(float64(ray.Azimuth), 0) - 1)
+		}
+		ray.Moments[DealiasedVelocity] = &Moment{Name: DealiasedVelocity, FirstGate: 2, GateInterval: 0.25, Gates: EncodeGates(values, 100, 32768)}
+	}
+
+	current := &Volume{Sweeps: []*Sweep{velocitySweep(10, wind)}}
+	current.Dealias(&Volume{Sweeps: []*Sweep{previous}})
+	checkDealiased(t, current.Sweeps[0], wind)
+}
@@ -0,0 +1,69 @@
+package level2
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of parsing error. Every error returned by the parsers can be matched against these with errors.Is
+var (
+	ErrTruncatedRecord = errors.New("truncated record")
+	ErrBadCompression  = errors.New("bad compression")
+	ErrUnknownFormat   = errors.New("unknown format")
+	ErrCorruptMessage  = errors.New("corrupt message")
+	ErrSizeLimit       = errors.New("record exceeds the configured size limit")
+)
+
+/*
+Error describes where in a file parsing failed. Offset is the position of the LDM record (or the
+volume header) in the file and RecordOffset is the position within the decompressed record, or -1
+when the error is not inside a record
+*/
+type Error struct {
+	Kind         error
+	Offset       int64
+	RecordOffset int64
+	MessageType  uint8
+	Err          error
+}
+
+func NewError(kind error, offset int64, err error) *Error {
+	return &Error{
+		Kind:         kind,
+		Offset:       offset,
+		RecordOffset: -1,
+		Err:          err,
+	}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s at offset %d", e.Kind, e.Offset)
+	if e.RecordOffset >= 0 {
+		msg += fmt.Sprintf(" (record offset %d", e.RecordOffset)
+		if e.MessageType != 0 {
+			msg += fmt.Sprintf(", message %d", e.MessageType)
+		}
+		msg += ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RecordError reports an error from decompressing an LDM record against the record's offset in the file
+func RecordError(err error, offset int64) error {
+	if e, ok := err.(*Error); ok {
+		e.Offset = offset
+		return e
+	}
+	return NewError(err, offset, nil)
+}
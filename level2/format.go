@@ -0,0 +1,155 @@
+package level2
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parser is the package that should be used to parse a file
+type Parser uint8
+
+const (
+	ParserUnknown Parser = iota
+	ParserNexrad         // level2/nexrad
+	ParserTDWR           // level2/tdwr
+)
+
+func (p Parser) String() string {
+	switch p {
+	case ParserNexrad:
+		return "nexrad"
+	case ParserTDWR:
+		return "tdwr"
+	}
+	return "unknown"
+}
+
+/*
+Format describes a Level II file. Full archives and the start (S) chunk of a real-time volume begin
+with a volume header, while intermediate (I) and end (E) chunks start straight into an LDM record
+*/
+type Format struct {
+	Tape            string // e.g. "AR2V0006.", empty if there is no volume header
+	Version         int    // Archive version from the tape, 0 for ARCHIVE2. or no volume header
+	HasVolumeHeader bool
+	Compressed      bool // Records are bzip2 compressed LDM records
+	Legacy          bool // Radials are legacy Message 1 rather than Message 31
+	Parser          Parser
+}
+
+// Tape strings of the archives parsed by level2/nexrad. ARCHIVE2. to AR2V0004 carry legacy Message 1
+// radials, later versions use Message 31
+var NexradTapes = []string{
+	"ARCHIVE2.",
+	"AR2V0001.",
+	"AR2V0002.",
+	"AR2V0003.",
+	"AR2V0004.",
+	"AR2V0005.",
+	"AR2V0006.",
+	"AR2V0007.",
+}
+
+// Tape strings of the archives parsed by level2/tdwr
+var TDWRTapes = []string{
+	"AR2V0008.",
+}
+
+func IsNexradTape(tape string) bool {
+	for _, t := range NexradTapes {
+		if tape == t {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLegacyTape reports whether archives with the tape hold legacy Message 1 radials
+func IsLegacyTape(tape string) bool {
+	switch tape {
+	case "ARCHIVE2.", "AR2V0001.", "AR2V0002.", "AR2V0003.", "AR2V0004.":
+		return true
+	}
+	return false
+}
+
+func IsTDWRTape(tape string) bool {
+	for _, t := range TDWRTapes {
+		if tape == t {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTDWRSite reports whether the ICAO is a TDWR site. TDWR identifiers start with T, e.g. TATL
+func IsTDWRSite(icao string) bool {
+	return len(icao) == 4 && icao[0] == 'T'
+}
+
+/*
+DetectSiteFormat is DetectFormat for files whose site is already known, e.g. from the name of a chunk
+in the real-time feed. Chunks without a volume header can't be told apart by their contents, so
+the site picks the parser for them
+*/
+func DetectSiteFormat(file io.ReadSeeker, icao string) (*Format, error) {
+	format, err := DetectFormat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !format.HasVolumeHeader && IsTDWRSite(icao) {
+		format.Parser = ParserTDWR
+	}
+	return format, nil
+}
+
+/*
+DetectFormat works out what kind of Level II file is being read from its volume header and the first
+record. The file is left at the start. ErrUnknownFormat is returned if it is not a Level II file
+*/
+func DetectFormat(file io.ReadSeeker) (*Format, error) {
+	file.Seek(0, io.SeekStart)
+	defer file.Seek(0, io.SeekStart)
+
+	start := make([]byte, FileHeaderSize+6)
+	n, err := io.ReadFull(file, start)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, NewError(ErrTruncatedRecord, 0, err)
+	}
+	start = start[:n]
+
+	format := Format{}
+
+	// Chunks without a volume header start with the LDM record size then the bzip2 magic. They are
+	// assumed to be NEXRAD, use DetectSiteFormat when the site is known
+	if n >= 6 && string(start[4:6]) == "BZ" {
+		format.Compressed = true
+		format.Parser = ParserNexrad
+		return &format, nil
+	}
+
+	if n < FileHeaderSize {
+		return nil, NewError(ErrUnknownFormat, 0, nil)
+	}
+
+	tape := string(start[:9])
+	switch {
+	case IsNexradTape(tape):
+		format.Parser = ParserNexrad
+	case IsTDWRTape(tape):
+		format.Parser = ParserTDWR
+	default:
+		return nil, NewError(ErrUnknownFormat, 0, nil)
+	}
+
+	format.Tape = tape
+	format.HasVolumeHeader = true
+	if strings.HasPrefix(tape, "AR2V") {
+		format.Version, _ = strconv.Atoi(tape[4:8])
+	}
+	format.Legacy = IsLegacyTape(tape)
+	format.Compressed = n >= FileHeaderSize+6 && string(start[FileHeaderSize+4:FileHeaderSize+6]) == "BZ"
+
+	return &format, nil
+}
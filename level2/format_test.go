@@ -0,0 +1,114 @@
+package level2
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// Reads a file from the test directory, skipping the test when it is not checked out
+func testFile(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile("../test/" + name)
+	if os.IsNotExist(err) {
+		t.Skipf("%s is not checked out", name)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDetectFormat(t *testing.T) {
+	start := testFile(t, "chunks/20240401-214657-001-S")
+	intermediate := testFile(t, "chunks/20240401-214657-002-I")
+	tdwr := testFile(t, "archive/TTUL20240418_132617_V08")
+
+	// The start chunk's volume header in front of its compressed record or an uncompressed message
+	withTape := func(tape string, compressed bool) []byte {
+		b := append([]byte{}, start[:FileHeaderSize]...)
+		copy(b, tape)
+		if compressed {
+			return append(b, start[FileHeaderSize:]...)
+		}
+		return append(b, make([]byte, CTMHeaderSize+MessageHeaderSize)...)
+	}
+
+	tests := []struct {
+		name string
+		file []byte
+		want Format
+	}{
+		{"ARCHIVE2.", withTape("ARCHIVE2.", false), Format{Tape: "ARCHIVE2.", HasVolumeHeader: true, Legacy: true, Parser: ParserNexrad}},
+		{"AR2V0001", withTape("AR2V0001.", false), Format{Tape: "AR2V0001.", Version: 1, HasVolumeHeader: true, Legacy: true, Parser: ParserNexrad}},
+		{"AR2V0002", withTape("AR2V0002.", true), Format{Tape: "AR2V0002.", Version: 2, HasVolumeHeader: true, Compressed: true, Legacy: true, Parser: ParserNexrad}},
+		{"AR2V0003", withTape("AR2V0003.", true), Format{Tape: "AR2V0003.", Version: 3, HasVolumeHeader: true, Compressed: true, Legacy: true, Parser: ParserNexrad}},
+		{"AR2V0004", withTape("AR2V0004.", true), Format{Tape: "AR2V0004.", Version: 4, HasVolumeHeader: true, Compressed: true, Legacy: true, Parser: ParserNexrad}},
+		{"AR2V0005", withTape("AR2V0005.", true), Format{Tape: "AR2V0005.", Version: 5, HasVolumeHeader: true, Compressed: true, Parser: ParserNexrad}},
+		{"AR2V0006 start chunk", start, Format{Tape: "AR2V0006.", Version: 6, HasVolumeHeader: true, Compressed: true, Parser: ParserNexrad}},
+		{"AR2V0006 uncompressed", withTape("AR2V0006.", false), Format{Tape: "AR2V0006.", Version: 6, HasVolumeHeader: true, Parser: ParserNexrad}},
+		{"AR2V0007", withTape("AR2V0007.", true), Format{Tape: "AR2V0007.", Version: 7, HasVolumeHeader: true, Compressed: true, Parser: ParserNexrad}},
+		{"AR2V0008", tdwr, Format{Tape: "AR2V0008.", Version: 8, HasVolumeHeader: true, Compressed: true, Parser: ParserTDWR}},
+		{"intermediate chunk", intermediate, Format{Compressed: true, Parser: ParserNexrad}},
+	}
+
+	for _, test := range tests {
+		file := bytes.NewReader(test.file)
+		file.Seek(100, io.SeekStart)
+		got, err := DetectFormat(file)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if *got != test.want {
+			t.Errorf("%s: format %+v, want %+v", test.name, *got, test.want)
+		}
+		if pos, _ := file.Seek(0, io.SeekCurrent); pos != 0 {
+			t.Errorf("%s: left the file at %d", test.name, pos)
+		}
+	}
+
+	for name, file := range map[string][]byte{
+		"unknown tape": withTape("AR2V0009.", true),
+		"short":        start[:10],
+		"empty":        {},
+	} {
+		if _, err := DetectFormat(bytes.NewReader(file)); !errors.Is(err, ErrUnknownFormat) && !errors.Is(err, ErrTruncatedRecord) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestDetectSiteFormat(t *testing.T) {
+	archive := testFile(t, "archive/TATL20240421_001129_V08")
+	start := testFile(t, "chunks/20240401-214657-001-S")
+	intermediate := testFile(t, "chunks/20240401-214657-002-I")
+
+	tests := []struct {
+		name string
+		file []byte
+		icao string
+		want Parser
+	}{
+		{"TDWR archive", archive, "TATL", ParserTDWR},
+		// Chunks after the first have no volume header, so only the site tells TDWR from NEXRAD
+		{"TDWR intermediate chunk", archive[FileHeaderSize:], "TATL", ParserTDWR},
+		{"NEXRAD intermediate chunk", intermediate, "KHDX", ParserNexrad},
+		// The volume header wins over the site
+		{"NEXRAD start chunk", start, "TATL", ParserNexrad},
+	}
+
+	for _, test := range tests {
+		got, err := DetectSiteFormat(bytes.NewReader(test.file), test.icao)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got.Parser != test.want {
+			t.Errorf("%s: parser %v, want %v", test.name, got.Parser, test.want)
+		}
+	}
+}
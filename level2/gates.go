@@ -0,0 +1,128 @@
+package level2
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// GateMask flags gates that hold one of the reserved codes rather than data
+type GateMask uint8
+
+const (
+	GateValid          GateMask = iota
+	GateBelowThreshold          // Raw value 0
+	GateRangeFolded             // Raw value 1
+)
+
+/*
+Gates holds the raw words of a moment as they were sent, one or two bytes per gate, and converts
+them to physical values on demand. This keeps a super-res volume at a quarter of the size it would
+be as float32s
+*/
+type Gates struct {
+	wordSize int // bytes
+	scale    float32
+	offset   float32
+	raw      []byte
+}
+
+// NewGates wraps raw moment words. wordSize is in bits and must be 8 or 16
+func NewGates(wordSize uint8, scale float32, offset float32, raw []byte) Gates {
+	return Gates{
+		wordSize: int(wordSize) / 8,
+		scale:    scale,
+		offset:   offset,
+		raw:      raw,
+	}
+}
+
+// Len returns the number of gates
+func (g Gates) Len() int {
+	if g.wordSize == 0 {
+		return 0
+	}
+	return len(g.raw) / g.wordSize
+}
+
+// Word returns the raw word of gate i
+func (g Gates) Word(i int) uint16 {
+	if g.wordSize == 2 {
+		return binary.BigEndian.Uint16(g.raw[i*2:])
+	}
+	return uint16(g.raw[i])
+}
+
+// Mask reports whether gate i holds data or one of the reserved codes
+func (g Gates) Mask(i int) GateMask {
+	switch g.Word(i) {
+	case 0:
+		return GateBelowThreshold
+	case 1:
+		return GateRangeFolded
+	}
+	return GateValid
+}
+
+// Value returns the physical value of gate i, or NaN if it holds a reserved code
+func (g Gates) Value(i int) float32 {
+	raw := g.Word(i)
+	switch {
+	case raw <= 1:
+		return float32(math.NaN())
+	case g.scale == 0:
+		return float32(raw)
+	}
+	return (float32(raw) - g.offset) / g.scale
+}
+
+// Float32s converts every gate, see Value
+func (g Gates) Float32s() []float32 {
+	values := make([]float32, g.Len())
+	for i := range values {
+		values[i] = g.Value(i)
+	}
+	return values
+}
+
+// Masks returns the mask of every gate, see Mask
+func (g Gates) Masks() []GateMask {
+	masks := make([]GateMask, g.Len())
+	for i := range masks {
+		masks[i] = g.Mask(i)
+	}
+	return masks
+}
+
+// Each calls fn with the value and mask of every gate in order
+func (g Gates) Each(fn func(i int, value float32, mask GateMask)) {
+	for i := 0; i < g.Len(); i++ {
+		fn(i, g.Value(i), g.Mask(i))
+	}
+}
+
+// Raw returns the raw words as they were sent. The slice is shared, not copied
+func (g Gates) Raw() []byte {
+	return g.raw
+}
+
+// WordSize returns the size of each word in bits
+func (g Gates) WordSize() uint8 {
+	return uint8(g.wordSize * 8)
+}
+
+/*
+EncodeGates stores values as 16 bit words with the given scale and offset, for moments worked out
+from others. NaN is stored as below threshold and values out of range are clamped
+*/
+func EncodeGates(values []float32, scale float32, offset float32) Gates {
+	raw := make([]byte, len(values)*2)
+	for i, v := range values {
+		if math.IsNaN(float64(v)) {
+			continue
+		}
+		word := math.Round(float64(v*scale + offset))
+		word = math.Max(2, math.Min(math.MaxUint16, word))
+		binary.BigEndian.PutUint16(raw[i*2:], uint16(word))
+	}
+	return NewGates(16, scale, offset, raw)
+}
@@ -1,3 +1,5 @@
 module github.com/TheRangiCrew/NEXRAD-GO/level2
 
 go 1.22.1
+
+require github.com/dsnet/compress v0.0.1
@@ -0,0 +1,169 @@
+package level2
+
+import "math"
+
+// KDPOptions tunes DeriveKDP. Zero fields take the defaults
+type KDPOptions struct {
+	Window   float32 // Length of the range window PHI is fit over, km. 2 if not set
+	MinRHO   float32 // Gates with a lower correlation coefficient, or one over 1 as noise has, are not fit. 0.9 if not set
+	MinGates int     // Fewest gates the window must have left to fit, half the gates in the window if not set
+}
+
+// How KDP is stored, to 0.01 deg/km
+const (
+	kdpScale  = 100
+	kdpOffset = 32768
+)
+
+/*
+DeriveKDP works out the specific differential phase of each ray from its PHI moment and adds it to
+the ray as the KDP moment, in deg/km. KDP is half the slope of a least squares fit of PHI against
+range over a window centred on each gate. Gates that are not rain, going by RHO when the ray has it,
+are left out of the fit and gates without enough of the window left either side of them are below
+threshold. The rays are changed, so sweeps sharing them see KDP too. It returns false if no ray has
+PHI
+*/
+func (s *Sweep) DeriveKDP(opts KDPOptions) bool {
+	found := false
+	for _, ray := range s.Rays {
+		if kdp := ray.KDP(opts); kdp != nil {
+			ray.Moments[kdp.Name] = kdp
+			found = true
+		}
+	}
+	return found
+}
+
+// KDP works out the KDP moment of the ray, see DeriveKDP. It returns nil if the ray has no PHI
+func (r *Ray) KDP(opts KDPOptions) *Moment {
+	phi, ok := r.Moments["PHI"]
+	if !ok || phi.Len() == 0 || phi.GateInterval <= 0 {
+		return nil
+	}
+	if opts.Window <= 0 {
+		opts.Window = 2
+	}
+	if opts.MinRHO <= 0 {
+		opts.MinRHO = 0.9
+	}
+	half := int(opts.Window / phi.GateInterval / 2)
+	if half < 1 {
+		half = 1
+	}
+	if opts.MinGates <= 0 {
+		opts.MinGates = half + 1
+	}
+	if opts.MinGates < 3 {
+		opts.MinGates = 3
+	}
+
+	rho := r.Moments["RHO"]
+	phase := unwrapPhase(phi, rho, opts.MinRHO)
+
+	// Running sums of the valid gates make each window's fit a subtraction
+	n := len(phase)
+	count := make([]float64, n+1)
+	sumR := make([]float64, n+1)
+	sumP := make([]float64, n+1)
+	sumRR := make([]float64, n+1)
+	sumRP := make([]float64, n+1)
+	for i, p := range phase {
+		count[i+1], sumR[i+1], sumP[i+1], sumRR[i+1], sumRP[i+1] = count[i], sumR[i], sumP[i], sumRR[i], sumRP[i]
+		if math.IsNaN(p) {
+			continue
+		}
+		rng := float64(phi.GateInterval) * float64(i)
+		count[i+1]++
+		sumR[i+1] += rng
+		sumP[i+1] += p
+		sumRR[i+1] += rng * rng
+		sumRP[i+1] += rng * p
+	}
+
+	kdp := make([]float32, n)
+	for i := range kdp {
+		kdp[i] = float32(math.NaN())
+		if math.IsNaN(phase[i]) {
+			continue
+		}
+
+		lo, hi := max(0, i-half), min(n, i+half+1)
+		c := count[hi] - count[lo]
+		if int(c) < opts.MinGates {
+			continue
+		}
+		// A fit to one side of the gate is a poor guess at the slope through it
+		if count[i+1]-count[lo] < c/4 || count[hi]-count[i] < c/4 {
+			continue
+		}
+		r := sumR[hi] - sumR[lo]
+		p := sumP[hi] - sumP[lo]
+		rr := sumRR[hi] - sumRR[lo]
+		rp := sumRP[hi] - sumRP[lo]
+
+		denominator := c*rr - r*r
+		if denominator <= 0 {
+			continue
+		}
+		kdp[i] = float32((c*rp - r*p) / denominator / 2)
+	}
+
+	return &Moment{
+		Name:         "KDP",
+		FirstGate:    phi.FirstGate,
+		GateInterval: phi.GateInterval,
+		Gates:        EncodeGates(kdp, kdpScale, kdpOffset),
+	}
+}
+
+// Fewest gates in a row that must pass RHO for their PHI to be used, which keeps out lone noisy gates
+const kdpMinRun = 5
+
+/*
+Returns PHI along the ray with the 360 degree folds taken out and NaN where there is no data, RHO
+is below minRHO or the gate is not part of a long enough run. RHO is matched to PHI by range as the
+two can have different gates
+*/
+func unwrapPhase(phi *Moment, rho *Moment, minRHO float32) []float64 {
+	phase := make([]float64, phi.Len())
+	for i := range phase {
+		phase[i] = math.NaN()
+
+		value := phi.Value(i)
+		if math.IsNaN(float64(value)) {
+			continue
+		}
+		if rho != nil && rho.GateInterval > 0 {
+			j := int(math.Round(float64((phi.FirstGate + float32(i)*phi.GateInterval - rho.FirstGate) / rho.GateInterval)))
+			if j < 0 || j >= rho.Len() || !(rho.Value(j) >= minRHO && rho.Value(j) <= 1) {
+				continue
+			}
+		}
+		phase[i] = float64(value)
+	}
+
+	previous := math.NaN()
+	for start := 0; start < len(phase); {
+		end := start
+		for end < len(phase) && !math.IsNaN(phase[end]) {
+			end++
+		}
+		if end == start {
+			start++
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			if end-start < kdpMinRun {
+				phase[i] = math.NaN()
+				continue
+			}
+			if !math.IsNaN(previous) {
+				phase[i] -= 360 * math.Round((phase[i]-previous)/360)
+			}
+			previous = phase[i]
+		}
+		start = end
+	}
+	return phase
+}
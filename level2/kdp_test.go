@@ -0,0 +1,67 @@
+package level2
+
+import (
+	"math"
+	"testing"
+)
+
+// A ray whose phase climbs at twice kdp deg/km from 300 degrees, folding past 360, with RHO of rho
+func kdpRay(kdp float64, rho []float32) *Ray {
+	phi := make([]float32, len(rho))
+	for i := range phi {
+		phi[i] = float32(math.Mod(300+2*kdp*0.25*float64(i), 360))
+	}
+	return &Ray{
+		Moments: map[string]*Moment{
+			"PHI": {Name: "PHI", FirstGate: 2, GateInterval: 0.25, Gates: EncodeGates(phi, 2.8361, 2)},
+			"RHO": {Name: "RHO", FirstGate: 2, GateInterval: 0.25, Gates: EncodeGates(rho, 300, -60.5)},
+		},
+	}
+}
+
+func TestKDP(t *testing.T) {
+	rho := make([]float32, 400)
+	for i := range rho {
+		rho[i] = 0.98
+		if i >= 100 && i < 120 {
+			rho[i] = 0.5
+		}
+	}
+	sweep := &Sweep{Rays: []*Ray{kdpRay(1.5, rho), {Moments: map[string]*Moment{}}}}
+
+	if !sweep.DeriveKDP(KDPOptions{}) {
+		t.Fatal("no KDP")
+	}
+	if _, ok := sweep.Rays[1].Moments["KDP"]; ok {
+		t.Error("KDP added to a ray without PHI")
+	}
+
+	kdp := sweep.Rays[0].Moments["KDP"]
+	if kdp.FirstGate != 2 || kdp.GateInterval != 0.25 || kdp.Len() != 400 {
+		t.Fatalf("KDP geometry %v %v %d", kdp.FirstGate, kdp.GateInterval, kdp.Len())
+	}
+	for i := 0; i < kdp.Len(); i++ {
+		value := kdp.Value(i)
+		if i >= 100 && i < 120 {
+			if kdp.Mask(i) != GateBelowThreshold {
+				t.Errorf("gate %d with low RHO is %v", i, value)
+			}
+			continue
+		}
+		// Gates near the ends of the data have too little of the window to fit
+		if i < 4 || (i > 95 && i < 124) || i > 395 {
+			continue
+		}
+		if kdp.Mask(i) != GateValid || math.Abs(float64(value)-1.5) > 0.1 {
+			t.Errorf("gate %d is %v, want 1.5", i, value)
+		}
+	}
+
+	// Without RHO every gate is fit
+	ray := kdpRay(-0.5, rho)
+	delete(ray.Moments, "RHO")
+	kdp = ray.KDP(KDPOptions{Window: 5})
+	if kdp.Mask(110) != GateValid || math.Abs(float64(kdp.Value(110))+0.5) > 0.05 {
+		t.Errorf("KDP without RHO is %v, want -0.5", kdp.Value(110))
+	}
+}
@@ -0,0 +1,173 @@
+package level2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Parts of Message 31 (Digital Radar Data Generic Format) that NEXRAD and TDWR radials share
+
+type Message31Header struct {
+	ICAO              [4]byte
+	CollectionTime    uint32
+	CollectionDate    uint16
+	AzimuthNumber     uint16
+	AzimuthAngle      float32
+	Compression       uint8
+	Spare             uint8
+	RadialLength      uint16
+	AzimuthResolution uint8 // 1 = 0.5, 2 = 1.0
+	RadialStatus      uint8
+	ElevationNumber   uint8
+	CutSectorNumber   uint8
+	ElevationAngle    float32
+	RadialBlanking    uint8
+	AzimuthIndexing   uint8
+	DataBlockCount    uint16
+	// Data block pointers
+}
+
+type GenericMoment struct {
+	DataBlockType       [1]byte
+	MomentName          [3]byte
+	Reserved            uint32
+	NumberGates         uint16
+	Range               uint16
+	RangeSampleInterval uint16
+	TOVER               uint16
+	SNRThreshold        uint16
+	ControlFlags        uint8
+	DataWordSize        uint8
+	Scale               float32
+	Offset              float32
+}
+
+// MomentBlock holds the raw gate words of a moment, use the methods of Gates to get physical values
+type MomentBlock struct {
+	GenericMoment
+	Gates
+}
+
+// The most data blocks a radial can have: VOL, ELV, RAD and up to seven moments
+const MaxDataBlocks = 10
+
+/*
+ParseRadial parses a Message 31 radial. The VOL, ELV and RAD blocks differ between NEXRAD and TDWR so
+blocks gives where each named block is read into, and only the moments named in moments are read.
+Every block pointer and moment is checked to lie inside RadialLength so that a corrupt or hostile
+radial returns an error instead of reading outside of the message or making huge allocations
+*/
+func ParseRadial(file io.ReadSeeker, blocks map[string]any, moments []string) (*Message31Header, map[string]MomentBlock, error) {
+	startPos, _ := file.Seek(0, io.SeekCurrent)
+
+	header := Message31Header{}
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, nil, err
+	}
+
+	headerSize := binary.Size(header) + int(header.DataBlockCount)*4
+	radialLength := int(header.RadialLength)
+	if header.DataBlockCount > MaxDataBlocks || radialLength < headerSize {
+		return nil, nil, fmt.Errorf("invalid radial length %d for %d data blocks", header.RadialLength, header.DataBlockCount)
+	}
+
+	blockPointers := make([]uint32, header.DataBlockCount)
+	if err := binary.Read(file, binary.BigEndian, blockPointers); err != nil {
+		return nil, nil, err
+	}
+
+	momentData := make(map[string]MomentBlock)
+
+	for _, pointer := range blockPointers {
+		// Unused pointers are left as zero
+		if pointer == 0 {
+			continue
+		}
+		if int64(pointer) < int64(headerSize) || int64(pointer)+4 > int64(radialLength) {
+			return nil, nil, fmt.Errorf("data block pointer %d outside of radial length %d", pointer, radialLength)
+		}
+
+		file.Seek(startPos+int64(pointer)+1, io.SeekStart)
+
+		n := make([]byte, 3)
+		if _, err := io.ReadFull(file, n); err != nil {
+			return nil, nil, err
+		}
+
+		file.Seek(-4, io.SeekCurrent)
+
+		name := string(n)
+
+		block, ok := blocks[name]
+		if !ok {
+			if !slices.Contains(moments, name) {
+				continue
+			}
+			block = &GenericMoment{}
+		}
+
+		end := int(pointer) + binary.Size(block)
+		if end > radialLength {
+			return nil, nil, fmt.Errorf("%s block ends at %d, past radial length %d", name, end, radialLength)
+		}
+		if err := binary.Read(file, binary.BigEndian, block); err != nil {
+			return nil, nil, err
+		}
+
+		m, ok := block.(*GenericMoment)
+		if !ok {
+			continue
+		}
+
+		if m.DataWordSize != 8 && m.DataWordSize != 16 {
+			return nil, nil, fmt.Errorf("%s has invalid data word size %d", name, m.DataWordSize)
+		}
+
+		ldm := int(m.NumberGates) * int(m.DataWordSize) / 8
+		if end+ldm > radialLength {
+			return nil, nil, fmt.Errorf("%s has %d gates, more than fit in radial length %d", name, m.NumberGates, radialLength)
+		}
+
+		data := make([]byte, ldm)
+		if _, err := io.ReadFull(file, data); err != nil {
+			return nil, nil, err
+		}
+
+		momentData[name] = MomentBlock{
+			GenericMoment: *m,
+			Gates:         NewGates(m.DataWordSize, m.Scale, m.Offset, data),
+		}
+	}
+
+	return &header, momentData, nil
+}
+
+/*
+Ray converts the radial header to the format-agnostic model. The Nyquist velocity (m/s) and
+unambiguous range (km) come from the RAD block, which differs between NEXRAD and TDWR
+*/
+func (h *Message31Header) Ray(nyquist float32, unambiguousRange float32, moments map[string]MomentBlock) *Ray {
+	ray := Ray{
+		Time:             JulianDateToTime(uint32(h.CollectionDate), h.CollectionTime),
+		AzimuthNumber:    int(h.AzimuthNumber),
+		Azimuth:          h.AzimuthAngle,
+		Elevation:        h.ElevationAngle,
+		Status:           RadialStatus(h.RadialStatus),
+		NyquistVelocity:  nyquist,
+		UnambiguousRange: unambiguousRange,
+		Moments:          make(map[string]*Moment, len(moments)),
+	}
+
+	for name, m := range moments {
+		ray.Moments[name] = &Moment{
+			Name:         name,
+			FirstGate:    float32(m.Range) / 1000.0,
+			GateInterval: float32(m.RangeSampleInterval) / 1000.0,
+			Gates:        m.Gates,
+		}
+	}
+
+	return &ray
+}
@@ -0,0 +1,114 @@
+package level2
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Message 5 (Volume Coverage Pattern), which NEXRAD and TDWR send in the same layout
+
+type ElevationCut struct {
+	ElevationAngle        Angle
+	ChannelConfig         ChannelConfig
+	Waveform              Waveform
+	SuperResControl       SuperResControl
+	SurveillanceNumber    uint8 // Surveillance PRF number
+	SurveillanceCount     uint16
+	AzimuthRate           AngleRate
+	ReflectivityThreshold SNRThreshold
+	VelocityThreshold     SNRThreshold
+	SWThreshold           SNRThreshold
+	DiffRefThreshold      SNRThreshold
+	DiffPhaseThreshold    SNRThreshold
+	CoorCoefThreshold     SNRThreshold
+	EdgeAngle             Angle
+	DopplePRF             uint16 // Doppler PRF number
+	DopplerPRFPulse       uint16
+	SupplementalData      CutSupplementalData
+	EdgeAngle2            Angle
+	DopplePRF2            uint16
+	DopplerPRFPulse2      uint16
+	EBCAngle              Angle
+	EdgeAngle3            Angle
+	DopplePRF3            uint16
+	DopplerPRFPulse3      uint16
+	Reserved              uint16
+}
+
+type Message5Header struct {
+	MessageSize         uint16
+	PatternType         uint16
+	PatternNumber       uint16
+	NumberOfCuts        uint16
+	VCPVersion          uint8
+	ClutterMapGroup     uint8
+	DopplerResolution   uint8
+	PulseWidth          uint8
+	_                   uint32
+	VCPSequencing       VCPSequencing
+	VCPSupplementalData VCPSupplementalData
+	_                   uint16
+}
+
+type Message5 struct {
+	Header          Message5Header
+	ElevationAngles []ElevationCut
+}
+
+func ParseMessage5(file io.ReadSeeker) (*Message5, error) {
+	header := Message5Header{}
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	m5 := Message5{
+		Header:          header,
+		ElevationAngles: []ElevationCut{},
+	}
+
+	for i := 0; i < int(m5.Header.NumberOfCuts); i++ {
+		elevationCut := ElevationCut{}
+		if err := binary.Read(file, binary.BigEndian, &elevationCut); err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		m5.ElevationAngles = append(m5.ElevationAngles, elevationCut)
+	}
+
+	return &m5, nil
+}
+
+// Elevation returns the elevation angle of the cut in degrees
+func (cut ElevationCut) Elevation() float32 {
+	return cut.ElevationAngle.Elevation()
+}
+
+// Sectors returns the azimuth of each sector edge and the Doppler PRF number used in each sector
+func (cut ElevationCut) Sectors() [3]PRFSector {
+	return [3]PRFSector{
+		{cut.EdgeAngle.Degrees(), int(cut.DopplePRF), int(cut.DopplerPRFPulse)},
+		{cut.EdgeAngle2.Degrees(), int(cut.DopplePRF2), int(cut.DopplerPRFPulse2)},
+		{cut.EdgeAngle3.Degrees(), int(cut.DopplePRF3), int(cut.DopplerPRFPulse3)},
+	}
+}
+
+type PRFSector struct {
+	EdgeAngle  float32 // degrees
+	PRFNumber  int
+	PulseCount int
+}
+
+// VelocityResolution returns the Doppler velocity resolution in m/s
+func (message Message5) VelocityResolution() float32 {
+	if message.Header.DopplerResolution == 4 {
+		return 1.0
+	}
+	return 0.5
+}
+
+func (message Message5) LongPulse() bool {
+	return message.Header.PulseWidth == 4
+}
@@ -0,0 +1,203 @@
+package level2
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+/*
+MessageReader reads the messages of a Level II file one at a time, decompressing its LDM records as
+they are reached. It leaves decoding the message bodies to the radar packages, which share it so that
+NEXRAD and TDWR files are framed the same way
+*/
+type MessageReader struct {
+	Format       Format
+	VolumeHeader VolumeHeader // Zero if the file has no volume header
+	// Number of LDM records to decompress at once. It must be set before the first call to Next and
+	// has no effect on uncompressed archives
+	Workers int
+
+	ctx           context.Context
+	limits        Limits
+	decompressed  int64
+	file          io.ReadSeeker
+	record        io.ReadSeeker
+	recordOffset  int64
+	messageOffset int64
+	records       <-chan Record
+}
+
+/*
+NewMessageReader works out the format of the file and reads its volume header if it has one. The
+reader stops with the context's error once it is cancelled and enforces the given size limits on each
+LDM record. The context is checked between LDM records
+*/
+func NewMessageReader(ctx context.Context, file io.ReadSeeker, limits Limits) (*MessageReader, error) {
+	format, err := DetectFormat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := MessageReader{
+		Format: *format,
+		ctx:    ctx,
+		limits: limits,
+		file:   file,
+	}
+
+	if format.HasVolumeHeader {
+		header, err := GetVolumeHeader(file)
+		if err != nil {
+			return nil, err
+		}
+		reader.VolumeHeader = *header
+	}
+
+	return &reader, nil
+}
+
+/*
+Next returns the header and body of the next message. The body of a Message 31 radial is its own
+length, while every other message is read as the whole of its fixed size frame. io.EOF is returned
+once there are no more messages
+*/
+func (r *MessageReader) Next() (*MessageHeader, *bytes.Reader, error) {
+	for {
+		if r.record == nil {
+			if err := r.nextRecord(); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		r.messageOffset, _ = r.record.Seek(CTMHeaderSize, io.SeekCurrent)
+
+		header := MessageHeader{}
+		if err := binary.Read(r.record, binary.BigEndian, &header); err != nil {
+			if err != io.EOF {
+				return nil, nil, r.MessageError(0, err)
+			}
+			r.record = nil
+			continue
+		}
+
+		if header.MessageType != 31 {
+			body := make([]byte, MessageBodySize)
+			// The last frame of a record can be cut short
+			n, err := io.ReadFull(r.record, body)
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return nil, nil, r.MessageError(header.MessageType, err)
+			}
+			return &header, bytes.NewReader(body[:n]), nil
+		}
+
+		// Read the radial on its own so that bad pointers can't reach into the next message
+		size := int(header.Size)*2 - MessageHeaderSize
+		if size < 0 {
+			return nil, nil, r.MessageError(header.MessageType, fmt.Errorf("invalid message size %d", header.Size))
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r.record, body); err != nil {
+			return nil, nil, r.MessageError(header.MessageType, err)
+		}
+		return &header, bytes.NewReader(body), nil
+	}
+}
+
+// MessageError wraps an error from decoding the last message read with where in the file it was
+func (r *MessageReader) MessageError(messageType uint8, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = NewError(ErrTruncatedRecord, r.recordOffset, err)
+	}
+	return &Error{
+		Kind:         ErrCorruptMessage,
+		Offset:       r.recordOffset,
+		RecordOffset: r.messageOffset,
+		MessageType:  messageType,
+		Err:          err,
+	}
+}
+
+// Moves the reader on to the next LDM record, decompressing it if needed
+func (r *MessageReader) nextRecord() error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+
+	if r.Workers > 1 && r.Format.Compressed {
+		return r.nextConcurrentRecord()
+	}
+
+	r.recordOffset, _ = r.file.Seek(0, io.SeekCurrent)
+
+	// Create the LDM Record
+	ldmRecord := LDM{}
+	if err := binary.Read(r.file, binary.BigEndian, &ldmRecord.Size); err != nil {
+		if err != io.EOF {
+			return NewError(ErrTruncatedRecord, r.recordOffset, err)
+		}
+		return io.EOF
+	}
+
+	if ldmRecord.Size < 0 {
+		ldmRecord.Size = -ldmRecord.Size
+	}
+
+	compressed, err := IsCompressed(r.file)
+	if err != nil {
+		return err
+	}
+
+	// Decompress the LDM Record
+	if compressed {
+		data, err := DecompressWithLimit(r.file, int(ldmRecord.Size), r.limits.MaxRecordSize, r.limits.MaxDecompressedSize)
+		if err != nil {
+			return RecordError(err, r.recordOffset)
+		}
+
+		r.decompressed += data.Size()
+		if r.limits.MaxVolumeSize > 0 && r.decompressed > r.limits.MaxVolumeSize {
+			return NewError(ErrSizeLimit, r.recordOffset, nil)
+		}
+
+		ldmRecord.Data = data
+	} else {
+		// Uncompressed (legacy) archives have no LDM records, just messages after the volume header
+		r.file.Seek(-4, io.SeekCurrent)
+		ldmRecord.Data = r.file
+	}
+
+	r.record = ldmRecord.Data
+
+	return nil
+}
+
+// Takes the next record from the workers, starting them on the first call
+func (r *MessageReader) nextConcurrentRecord() error {
+	if r.records == nil {
+		r.records = DecompressRecords(r.ctx, r.file, r.limits, r.Workers)
+	}
+
+	record, ok := <-r.records
+	if !ok {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	if record.Err != nil {
+		return record.Err
+	}
+
+	r.recordOffset = record.Offset
+	r.decompressed += record.Data.Size()
+	if r.limits.MaxVolumeSize > 0 && r.decompressed > r.limits.MaxVolumeSize {
+		return NewError(ErrSizeLimit, r.recordOffset, nil)
+	}
+
+	r.record = record.Data
+
+	return nil
+}
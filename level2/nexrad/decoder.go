@@ -0,0 +1,123 @@
+package nexrad
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Message is a single decoded message. Data holds the parsed body, e.g. *Message31, *level2.Message5 or
+// *RDAStatus, and is nil for message types that are not decoded
+type Message struct {
+	Header level2.MessageHeader
+	Data   any
+}
+
+/*
+Decoder reads a Level II file one message at a time so that radials can be handled as soon as they
+are read instead of waiting for the whole file to be parsed
+*/
+type Decoder struct {
+	IsArchive    bool
+	ICAO         string
+	VolumeHeader level2.VolumeHeader
+	// Number of LDM records to decompress at once. It must be set before the first call to Next and
+	// has no effect on uncompressed archives
+	Workers int
+
+	messages *level2.MessageReader
+	segments map[uint8]*segmentedMessage
+}
+
+func NewDecoder(file io.ReadSeeker) (*Decoder, error) {
+	return NewDecoderContext(context.Background(), file, level2.DefaultLimits)
+}
+
+/*
+NewDecoderContext creates a Decoder that stops with the context's error once it is cancelled and
+enforces the given size limits on each LDM record. The context is checked between LDM records
+*/
+func NewDecoderContext(ctx context.Context, file io.ReadSeeker, limits level2.Limits) (*Decoder, error) {
+	messages, err := level2.NewMessageReader(ctx, file, limits)
+	if err != nil {
+		return nil, err
+	}
+	if messages.Format.Parser != level2.ParserNexrad {
+		return nil, level2.NewError(level2.ErrUnknownFormat, 0, fmt.Errorf("%s is not a NEXRAD archive", messages.Format.Tape))
+	}
+
+	decoder := Decoder{
+		IsArchive:    messages.Format.HasVolumeHeader,
+		VolumeHeader: messages.VolumeHeader,
+		messages:     messages,
+		segments:     map[uint8]*segmentedMessage{},
+	}
+
+	// If the file is an archive file then the ICAO is provided for us already
+	if decoder.IsArchive {
+		decoder.ICAO = string(decoder.VolumeHeader.ICAO[:])
+	}
+
+	return &decoder, nil
+}
+
+// Next returns the next message in the file. io.EOF is returned once there are no more messages
+func (d *Decoder) Next() (*Message, error) {
+	d.messages.Workers = d.Workers
+
+	for {
+		header, body, err := d.messages.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		message := Message{
+			Header: *header,
+		}
+
+		switch header.MessageType {
+		case 1:
+			message.Data, err = ParseMessage1(body)
+		case 2:
+			message.Data, err = ParseMessage2(body)
+		case 3:
+			message.Data, err = ParseMessage3(body)
+		case 5:
+			message.Data, err = level2.ParseMessage5(body)
+		case 31:
+			var m31 *Message31
+			m31, err = ParseMessage31(body)
+			if err == nil && int(m31.Header.RadialLength) > int(body.Size()) {
+				err = fmt.Errorf("radial length %d is longer than the message size %d", m31.Header.RadialLength, body.Size())
+			}
+			if err == nil && d.ICAO == "" {
+				d.ICAO = string(m31.Header.ICAO[:])
+			}
+			message.Data = m31
+		case 13, 15, 18:
+			var data io.ReadSeeker
+			data, err = readSegment(body, *header, d.segments)
+			// Wait for the rest of the segments
+			if err == nil && data == nil {
+				continue
+			}
+			if err == nil {
+				switch header.MessageType {
+				case 13:
+					message.Data, err = ParseMessage13(data)
+				case 15:
+					message.Data, err = ParseMessage15(data)
+				case 18:
+					message.Data, err = ParseMessage18(data)
+				}
+			}
+		}
+		if err != nil {
+			return nil, d.messages.MessageError(header.MessageType, err)
+		}
+
+		return &message, nil
+	}
+}
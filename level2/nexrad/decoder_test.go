@@ -0,0 +1,43 @@
+package nexrad
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+func TestParseNexradConcurrent(t *testing.T) {
+	archive := syntheticArchive(t, 3, 360, EncodeOptions{RadialsPerRecord: 40})
+
+	want, err := ParseNexrad(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{2, 4, 16} {
+		got, err := ParseNexradConcurrent(context.Background(), bytes.NewReader(archive), level2.DefaultLimits, workers)
+		if err != nil {
+			t.Fatalf("%d workers: %v", workers, err)
+		}
+		// Radials are compared in order so this also checks records were handed on in file order
+		compareRadars(t, want, got)
+	}
+}
+
+func BenchmarkParseNexrad(b *testing.B) {
+	archive := syntheticArchive(b, 6, 720, EncodeOptions{})
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(archive)))
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseNexradConcurrent(context.Background(), bytes.NewReader(archive), level2.DefaultLimits, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
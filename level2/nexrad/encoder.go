@@ -0,0 +1,211 @@
+package nexrad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// EncodeOptions trims what is written by Encode. Empty fields write everything
+type EncodeOptions struct {
+	Elevations       []int    // Elevation numbers to write
+	Moments          []string // Moment names to write, e.g. "REF", "VEL" or "SW "
+	RadialsPerRecord int      // Radials in each LDM record, 120 if not set
+}
+
+const defaultRadialsPerRecord = 120
+
+/*
+Encode writes the radar as an AR2V archive of bzip2 compressed LDM records: the volume header, a
+metadata record holding Message 5 and then the Message 31 radials of each elevation. Messages 2, 3,
+13, 15 and 18 are dropped, so the RDA status, performance data, clutter maps and adaptation data of
+the radar do not survive a round trip
+*/
+func Encode(w io.Writer, radar *Nexrad, opts EncodeOptions) error {
+	if opts.RadialsPerRecord <= 0 {
+		opts.RadialsPerRecord = defaultRadialsPerRecord
+	}
+
+	elevations := make([]int, 0, len(radar.ElevationScans))
+	for k := range radar.ElevationScans {
+		if len(opts.Elevations) == 0 || slices.Contains(opts.Elevations, k) {
+			elevations = append(elevations, k)
+		}
+	}
+	slices.Sort(elevations)
+
+	radials := []*Message31{}
+	for _, elevation := range elevations {
+		radials = append(radials, radar.ElevationScans[elevation].M31...)
+	}
+
+	header := radar.VolumeHeader
+	// Legacy archives hold Message 1 radials but they are always written as Message 31
+	if !radar.IsArchive || !level2.IsNexradTape(string(header.Tape[:])) || level2.IsLegacyTape(string(header.Tape[:])) {
+		copy(header.Tape[:], "AR2V0006.")
+		copy(header.Extension[:], "001")
+		copy(header.ICAO[:], radar.ICAO)
+		if len(radials) > 0 {
+			header.JulianDate = uint32(radials[0].Header.CollectionDate)
+			header.Time = radials[0].Header.CollectionTime
+		}
+	}
+
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return err
+	}
+
+	e := encoder{
+		w:        w,
+		date:     uint16(header.JulianDate),
+		time:     header.Time,
+		sequence: 0,
+	}
+
+	// The metadata record is always written, even when there is no VCP to put in it
+	metadata := bytes.NewBuffer([]byte{})
+	if radar.VCP != nil {
+		if err := e.writeMessage5(metadata, radar.VCP); err != nil {
+			return err
+		}
+	}
+	if err := e.writeRecord(metadata.Bytes()); err != nil {
+		return err
+	}
+
+	record := bytes.NewBuffer([]byte{})
+	for i, m31 := range radials {
+		if err := e.writeMessage31(record, m31, opts.Moments); err != nil {
+			return err
+		}
+		if (i+1)%opts.RadialsPerRecord == 0 || i == len(radials)-1 {
+			if err := e.writeRecord(record.Bytes()); err != nil {
+				return err
+			}
+			record.Reset()
+		}
+	}
+
+	return nil
+}
+
+type encoder struct {
+	w        io.Writer
+	date     uint16
+	time     uint32
+	sequence uint16
+}
+
+// Compresses the record and writes it with its LDM size
+func (e *encoder) writeRecord(record []byte) error {
+	compressed, err := level2.Compress(record)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(e.w, binary.BigEndian, int32(len(compressed))); err != nil {
+		return err
+	}
+	_, err = e.w.Write(compressed)
+	return err
+}
+
+// Writes the CTM and message headers. size is the length of the message body in bytes
+func (e *encoder) writeHeader(buf *bytes.Buffer, messageType uint8, size int, date uint16, time uint32) {
+	buf.Write(make([]byte, level2.CTMHeaderSize))
+
+	header := level2.MessageHeader{
+		Size:           uint16((size + level2.MessageHeaderSize) / 2),
+		Channel:        8,
+		MessageType:    messageType,
+		SequenceNumber: e.sequence,
+		JulianDate:     date,
+		DayMS:          time,
+		Segments:       1,
+		SegmentNumber:  1,
+	}
+	binary.Write(buf, binary.BigEndian, header)
+
+	e.sequence = (e.sequence + 1) & 0x7FFF
+}
+
+func (e *encoder) writeMessage5(buf *bytes.Buffer, m5 *level2.Message5) error {
+	body := bytes.NewBuffer([]byte{})
+
+	header := m5.Header
+	header.NumberOfCuts = uint16(len(m5.ElevationAngles))
+	if err := binary.Write(body, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(body, binary.BigEndian, m5.ElevationAngles); err != nil {
+		return err
+	}
+
+	e.writeHeader(buf, 5, body.Len(), e.date, e.time)
+	buf.Write(body.Bytes())
+
+	// Fixed length messages are padded out to a whole frame
+	buf.Write(make([]byte, level2.MessageBodySize-body.Len()))
+
+	return nil
+}
+
+func (e *encoder) writeMessage31(buf *bytes.Buffer, m31 *Message31, moments []string) error {
+	blocks := []any{&m31.VolumeData, &m31.ElevationData, &m31.RadialData}
+
+	for _, name := range momentNames {
+		m, ok := m31.MomentData[name]
+		if !ok || (len(moments) > 0 && !slices.Contains(moments, name)) {
+			continue
+		}
+		blocks = append(blocks, m)
+	}
+
+	header := m31.Header
+	header.DataBlockCount = uint16(len(blocks))
+
+	// Work out where each block will start
+	pointer := binary.Size(header) + len(blocks)*4
+	pointers := make([]uint32, len(blocks))
+	body := bytes.NewBuffer([]byte{})
+	for i, block := range blocks {
+		pointers[i] = uint32(pointer)
+
+		start := body.Len()
+		switch b := block.(type) {
+		case level2.MomentBlock:
+			m := b.GenericMoment
+			m.NumberGates = uint16(b.Len())
+			m.DataWordSize = b.WordSize()
+			if err := binary.Write(body, binary.BigEndian, m); err != nil {
+				return err
+			}
+			body.Write(b.Raw())
+		default:
+			if err := binary.Write(body, binary.BigEndian, block); err != nil {
+				return err
+			}
+		}
+		pointer += body.Len() - start
+	}
+
+	// Message sizes are counted in halfwords
+	if pointer%2 != 0 {
+		body.WriteByte(0)
+		pointer++
+	}
+	header.RadialLength = uint16(pointer)
+
+	e.writeHeader(buf, 31, pointer, header.CollectionDate, header.CollectionTime)
+	if err := binary.Write(buf, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(buf, binary.BigEndian, pointers); err != nil {
+		return err
+	}
+	buf.Write(body.Bytes())
+
+	return nil
+}
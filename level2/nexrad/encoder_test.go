@@ -0,0 +1,156 @@
+package nexrad
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// Checks that every radial of want was decoded from got without losing anything
+func compareRadars(t *testing.T, want *Nexrad, got *Nexrad) {
+	t.Helper()
+
+	if want.VCP != nil {
+		if got.VCP == nil {
+			t.Fatal("VCP was not written")
+		}
+		if got.VCP.Header != want.VCP.Header {
+			t.Errorf("VCP header = %+v, want %+v", got.VCP.Header, want.VCP.Header)
+		}
+		if len(got.VCP.ElevationAngles) != len(want.VCP.ElevationAngles) {
+			t.Fatalf("%d cuts, want %d", len(got.VCP.ElevationAngles), len(want.VCP.ElevationAngles))
+		}
+		for i, cut := range want.VCP.ElevationAngles {
+			if got.VCP.ElevationAngles[i] != cut {
+				t.Errorf("cut %d = %+v, want %+v", i+1, got.VCP.ElevationAngles[i], cut)
+			}
+		}
+	}
+
+	if len(got.ElevationScans) != len(want.ElevationScans) {
+		t.Fatalf("%d elevations, want %d", len(got.ElevationScans), len(want.ElevationScans))
+	}
+
+	for elevation, scan := range want.ElevationScans {
+		radials := got.ElevationScans[elevation]
+		if radials == nil || len(radials.M31) != len(scan.M31) {
+			t.Fatalf("elevation %d has the wrong number of radials", elevation)
+		}
+
+		for i, w := range scan.M31 {
+			g := radials.M31[i]
+
+			// The encoder works out the block count and length for itself
+			header := w.Header
+			header.DataBlockCount = g.Header.DataBlockCount
+			header.RadialLength = g.Header.RadialLength
+			if g.Header != header {
+				t.Fatalf("elevation %d radial %d header = %+v, want %+v", elevation, i, g.Header, header)
+			}
+			if g.VolumeData != w.VolumeData || g.ElevationData != w.ElevationData || g.RadialData != w.RadialData {
+				t.Fatalf("elevation %d radial %d VOL, ELV or RAD block changed", elevation, i)
+			}
+
+			if len(g.MomentData) != len(w.MomentData) {
+				t.Fatalf("elevation %d radial %d has %d moments, want %d", elevation, i, len(g.MomentData), len(w.MomentData))
+			}
+			for name, m := range w.MomentData {
+				gm := g.MomentData[name]
+				if gm.GenericMoment != m.GenericMoment {
+					t.Fatalf("elevation %d radial %d %s block = %+v, want %+v", elevation, i, name, gm.GenericMoment, m.GenericMoment)
+				}
+				if !bytes.Equal(gm.Raw(), m.Raw()) {
+					t.Fatalf("elevation %d radial %d %s gates changed", elevation, i, name)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	want := syntheticRadar(3, 360)
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := Encode(buf, want, EncodeOptions{RadialsPerRecord: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseNexrad(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got.VolumeHeader.Tape[:]) != "AR2V0006." || got.ICAO != "KTST" {
+		t.Errorf("volume header = %+v", got.VolumeHeader)
+	}
+	first := want.ElevationScans[1].M31[0].Header
+	if got.VolumeHeader.JulianDate != uint32(first.CollectionDate) || got.VolumeHeader.Time != first.CollectionTime {
+		t.Errorf("volume header date %d %d, want %d %d", got.VolumeHeader.JulianDate, got.VolumeHeader.Time, first.CollectionDate, first.CollectionTime)
+	}
+
+	compareRadars(t, want, got)
+}
+
+func TestEncodeOptions(t *testing.T) {
+	radar := syntheticRadar(3, 40)
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := Encode(buf, radar, EncodeOptions{Elevations: []int{2}, Moments: []string{"VEL"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseNexrad(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.ElevationScans) != 1 || got.ElevationScans[2] == nil {
+		t.Fatalf("elevations %v, want only 2", got.ElevationScans)
+	}
+	for _, m31 := range got.ElevationScans[2].M31 {
+		if _, ok := m31.MomentData["REF"]; ok || len(m31.MomentData) != 1 {
+			t.Fatal("moments were not trimmed to VEL")
+		}
+	}
+}
+
+/*
+Re-encodes the chunks from the live feed, which are only there when test/chunks is checked out. The
+V08 archives in test/archive are TDWR, which Encode does not write
+*/
+func TestEncodeChunks(t *testing.T) {
+	chunks, _ := filepath.Glob("../../test/chunks/*-[SIE]")
+	if len(chunks) == 0 {
+		t.Skip("no chunks in test/chunks")
+	}
+	sort.Strings(chunks)
+
+	for _, chunk := range chunks {
+		b, err := os.ReadFile(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ParseNexrad(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+		if err := Encode(buf, want, EncodeOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseNexrad(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", chunk, err)
+		}
+
+		compareRadars(t, want, got)
+
+		// Only Message 5 is kept of the metadata the start chunk carries
+		if want.Adaptation != nil && (got.Adaptation != nil || got.RDAStatus != nil || got.Performance != nil || got.ClutterMap != nil) {
+			t.Errorf("%s: metadata other than the VCP was written", chunk)
+		}
+	}
+}
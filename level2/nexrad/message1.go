@@ -0,0 +1,164 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Message1Header is the header of the legacy (pre-Build 10) Message 1 Digital Radar Data
+type Message1Header struct {
+	CollectionTime         uint32
+	CollectionDate         uint16
+	UnambiguousRange       uint16 // Scaled by 10, km
+	AzimuthAngle           uint16 // Coded, see DecodeLegacyAngle
+	AzimuthNumber          uint16
+	RadialStatus           uint16
+	ElevationAngle         uint16 // Coded, see DecodeLegacyAngle
+	ElevationNumber        uint16
+	SurveillanceRange      int16  // m
+	DopplerRange           int16  // m
+	SurveillanceInterval   uint16 // m
+	DopplerInterval        uint16 // m
+	SurveillanceGates      uint16
+	DopplerGates           uint16
+	CutSectorNumber        uint16
+	CalibrationConstant    float32
+	SurveillancePointer    uint16
+	VelocityPointer        uint16
+	SpectrumWidthPointer   uint16
+	VelocityResolution     uint16 // 2 = 0.5 m/s, 4 = 1.0 m/s
+	VCP                    uint16
+	_                      [4]uint16
+	SurveillancePlayback   uint16
+	VelocityPlayback       uint16
+	SpectrumWidthPlayback  uint16
+	NyquistVelocity        uint16 // Scaled by 100, m/s
+	AtmosphericAttenuation int16  // Scaled by 1000, dB/km
+	TOVER                  uint16 // Scaled by 10, dB
+	SpotBlankingStatus     uint16
+	_                      [14]uint16
+}
+
+type Message1 struct {
+	Header     Message1Header
+	MomentData map[string]level2.MomentBlock
+}
+
+// DecodeLegacyAngle converts a Message 1 coded azimuth or elevation angle to degrees
+func DecodeLegacyAngle(coded uint16) float32 {
+	return float32(coded>>3) * (180.0 / 4096.0)
+}
+
+func ParseMessage1(file io.ReadSeeker) (*Message1, error) {
+	header := Message1Header{}
+
+	startPos, _ := file.Seek(0, io.SeekCurrent)
+
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	message1 := Message1{
+		Header:     header,
+		MomentData: make(map[string]level2.MomentBlock),
+	}
+
+	velocityScale := float32(2.0)
+	if header.VelocityResolution == 4 {
+		velocityScale = 1.0
+	}
+
+	blocks := []struct {
+		name     string
+		pointer  uint16
+		gates    uint16
+		rng      int16
+		interval uint16
+		scale    float32
+		offset   float32
+	}{
+		{"REF", header.SurveillancePointer, header.SurveillanceGates, header.SurveillanceRange, header.SurveillanceInterval, 2.0, 66.0},
+		{"VEL", header.VelocityPointer, header.DopplerGates, header.DopplerRange, header.DopplerInterval, velocityScale, 129.0},
+		{"SW ", header.SpectrumWidthPointer, header.DopplerGates, header.DopplerRange, header.DopplerInterval, 2.0, 129.0},
+	}
+
+	for _, block := range blocks {
+		if block.pointer == 0 || block.gates == 0 {
+			continue
+		}
+
+		file.Seek(startPos+int64(block.pointer), io.SeekStart)
+
+		data := make([]byte, block.gates)
+		if err := binary.Read(file, binary.BigEndian, data); err != nil {
+			return nil, err
+		}
+
+		// Message 31 ranges can't be negative, so gates behind the radar are dropped
+		rng := int(block.rng)
+		if rng < 0 && block.interval > 0 {
+			skip := (-rng + int(block.interval) - 1) / int(block.interval)
+			if skip >= len(data) {
+				continue
+			}
+			data = data[skip:]
+			rng += skip * int(block.interval)
+		}
+
+		m := level2.GenericMoment{
+			MomentName:          [3]byte{block.name[0], block.name[1], block.name[2]},
+			NumberGates:         uint16(len(data)),
+			Range:               uint16(rng),
+			RangeSampleInterval: block.interval,
+			TOVER:               header.TOVER,
+			DataWordSize:        8,
+			Scale:               block.scale,
+			Offset:              block.offset,
+		}
+
+		d := level2.MomentBlock{
+			GenericMoment: m,
+			Gates:         level2.NewGates(m.DataWordSize, m.Scale, m.Offset, data),
+		}
+
+		message1.MomentData[block.name] = d
+	}
+
+	file.Seek(startPos+level2.MessageBodySize, io.SeekStart)
+
+	return &message1, nil
+}
+
+// ToMessage31 represents the legacy radial as a Message 31 radial so that it can be
+// used anywhere Message 31 data is expected
+func (m1 *Message1) ToMessage31(icao string) *Message31 {
+	header := level2.Message31Header{
+		CollectionTime:    m1.Header.CollectionTime,
+		CollectionDate:    m1.Header.CollectionDate,
+		AzimuthNumber:     m1.Header.AzimuthNumber,
+		AzimuthAngle:      DecodeLegacyAngle(m1.Header.AzimuthAngle),
+		AzimuthResolution: 2,
+		RadialStatus:      uint8(m1.Header.RadialStatus),
+		ElevationNumber:   uint8(m1.Header.ElevationNumber),
+		CutSectorNumber:   uint8(m1.Header.CutSectorNumber),
+		ElevationAngle:    DecodeLegacyAngle(m1.Header.ElevationAngle),
+	}
+	copy(header.ICAO[:], icao)
+
+	return &Message31{
+		Header: header,
+		VolumeData: VolumeData{
+			DataName:            [3]byte{'V', 'O', 'L'},
+			CalibrationConstant: m1.Header.CalibrationConstant,
+			VCP:                 m1.Header.VCP,
+		},
+		RadialData: RadialData{
+			DataName: [3]byte{'R', 'A', 'D'},
+			Range:    m1.Header.UnambiguousRange,
+			Velocity: m1.Header.NyquistVelocity,
+		},
+		MomentData: m1.MomentData,
+	}
+}
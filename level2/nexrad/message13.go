@@ -0,0 +1,77 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+const (
+	BypassMapRadials     = 360
+	BypassMapRangeBins   = 512
+	MaxBypassMapSegments = 2 // The RDA sends at most two elevation segments
+)
+
+// BypassMapSegment is a bit map of 512 range bins for each 1 degree radial,
+// starting at 0 degrees. A set bit means the clutter filter is bypassed
+type BypassMapSegment struct {
+	SegmentNumber uint16
+	Radials       [BypassMapRadials][BypassMapRangeBins / 16]uint16
+}
+
+// BypassMap is the body of Message 13 (Clutter Filter Bypass Map)
+type BypassMap struct {
+	GenerationDate    uint16
+	GenerationTime    uint16 // Minutes past midnight
+	ElevationSegments []BypassMapSegment
+}
+
+func ParseMessage13(file io.ReadSeeker) (*BypassMap, error) {
+	header := struct {
+		GenerationDate    uint16
+		GenerationTime    uint16
+		ElevationSegments uint16
+	}{}
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	// The count is checked before anything is allocated for it
+	if header.ElevationSegments > MaxBypassMapSegments {
+		return nil, fmt.Errorf("bypass map has %d elevation segments, more than %d", header.ElevationSegments, MaxBypassMapSegments)
+	}
+	if size := int64(header.ElevationSegments) * int64(binary.Size(BypassMapSegment{})); size > remaining(file) {
+		return nil, fmt.Errorf("bypass map has %d elevation segments, more than fit in the message", header.ElevationSegments)
+	}
+
+	m13 := BypassMap{
+		GenerationDate:    header.GenerationDate,
+		GenerationTime:    header.GenerationTime,
+		ElevationSegments: make([]BypassMapSegment, header.ElevationSegments),
+	}
+
+	for s := range m13.ElevationSegments {
+		if err := binary.Read(file, binary.BigEndian, &m13.ElevationSegments[s]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m13, nil
+}
+
+func (m BypassMap) Generated() time.Time {
+	return level2.JulianDateToTime(uint32(m.GenerationDate), uint32(m.GenerationTime)*60*1000)
+}
+
+// Bypassed reports whether the clutter filter is bypassed for the given radial
+// and range bin
+func (s BypassMapSegment) Bypassed(radial int, bin int) bool {
+	if radial < 0 || radial >= BypassMapRadials || bin < 0 || bin >= BypassMapRangeBins {
+		return false
+	}
+
+	return s.Radials[radial][bin/16]&(1<<(15-bin%16)) != 0
+}
@@ -0,0 +1,92 @@
+package nexrad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A Message 13 body of the given number of segments with range bin 100 of radial 45 bypassed
+func bypassMap(segments uint16) []byte {
+	buf := bytes.NewBuffer([]byte{})
+	binary.Write(buf, binary.BigEndian, []uint16{19815, 1301, segments})
+	for s := uint16(1); s <= segments; s++ {
+		segment := BypassMapSegment{SegmentNumber: s}
+		segment.Radials[45][100/16] = 1 << (15 - 100%16)
+		binary.Write(buf, binary.BigEndian, &segment)
+	}
+	return buf.Bytes()
+}
+
+func TestParseMessage13(t *testing.T) {
+	m13, err := ParseMessage13(bytes.NewReader(bypassMap(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := m13.Generated(), time.Date(2024, 4, 1, 21, 41, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("generated %v, want %v", got, want)
+	}
+	if len(m13.ElevationSegments) != 2 || m13.ElevationSegments[1].SegmentNumber != 2 {
+		t.Fatalf("%d segments", len(m13.ElevationSegments))
+	}
+	segment := m13.ElevationSegments[0]
+	if !segment.Bypassed(45, 100) || segment.Bypassed(45, 101) || segment.Bypassed(46, 100) || segment.Bypassed(360, 100) {
+		t.Error("wrong bins bypassed")
+	}
+
+	// A third segment is more than the RDA sends, even when the message holds it
+	_, err = ParseMessage13(bytes.NewReader(bypassMap(MaxBypassMapSegments + 1)))
+	if err == nil || !strings.Contains(err.Error(), "elevation segments") {
+		t.Errorf("err = %v, want too many segments", err)
+	}
+}
+
+// A Message 15 body of the given number of segments where every azimuth has zones range zones
+func clutterFilterMap(segments uint16, zones uint16) []byte {
+	buf := bytes.NewBuffer([]byte{})
+	binary.Write(buf, binary.BigEndian, []uint16{19721, 1043, segments})
+	for a := 0; a < ClutterFilterAzimuths; a++ {
+		binary.Write(buf, binary.BigEndian, zones)
+		for z := uint16(1); z <= zones; z++ {
+			binary.Write(buf, binary.BigEndian, RangeZone{OpCode: ClutterForceFilter, EndRange: 10 * z})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestParseMessage15(t *testing.T) {
+	m15, err := ParseMessage15(bytes.NewReader(chunkMessage(t, startChunk, 15)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := m15.Generated(), time.Date(2023, 12, 29, 17, 23, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("generated %v, want %v", got, want)
+	}
+	if len(m15.ElevationSegments) != 5 {
+		t.Fatalf("%d segments, want 5", len(m15.ElevationSegments))
+	}
+	if zones := m15.ElevationSegments[0].Azimuths[0]; len(zones) != 1 || zones[0] != (RangeZone{OpCode: ClutterBypassMap, EndRange: 511}) {
+		t.Errorf("azimuth 0 zones %v", zones)
+	}
+
+	tests := map[string][]byte{
+		"segments":    clutterFilterMap(MaxClutterSegments+1, 1),
+		"range zones": clutterFilterMap(1, MaxRangeZones+1),
+	}
+	for name, body := range tests {
+		if _, err := ParseMessage15(bytes.NewReader(body)); err == nil || !strings.Contains(err.Error(), "more than") {
+			t.Errorf("%s: err = %v, want too many %s", name, err, name)
+		}
+	}
+	m15, err = ParseMessage15(bytes.NewReader(clutterFilterMap(1, MaxRangeZones)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op := m15.ElevationSegments[0].OpCode(-90, 15); op != ClutterForceFilter {
+		t.Errorf("op code %d, want %d", op, ClutterForceFilter)
+	}
+}
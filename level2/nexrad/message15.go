@@ -0,0 +1,105 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+const (
+	ClutterBypassFilter   = 0 // Bypass the clutter filter
+	ClutterBypassMap      = 1 // Bypass map in control
+	ClutterForceFilter    = 2 // Force the clutter filter on
+	ClutterFilterAzimuths = 360
+	MaxClutterSegments    = 5  // The RDA sends at most five elevation segments
+	MaxRangeZones         = 20 // Range zones in each azimuth
+)
+
+type RangeZone struct {
+	OpCode   uint16
+	EndRange uint16 // km
+}
+
+// ClutterFilterSegment holds the range zones for each 1 degree azimuth radial,
+// starting at 0 degrees
+type ClutterFilterSegment struct {
+	Azimuths [ClutterFilterAzimuths][]RangeZone
+}
+
+// ClutterFilterMap is the body of Message 15 (Clutter Filter Map)
+type ClutterFilterMap struct {
+	GenerationDate    uint16
+	GenerationTime    uint16 // Minutes past midnight
+	ElevationSegments []ClutterFilterSegment
+}
+
+func ParseMessage15(file io.ReadSeeker) (*ClutterFilterMap, error) {
+	header := struct {
+		GenerationDate    uint16
+		GenerationTime    uint16
+		ElevationSegments uint16
+	}{}
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	// The counts are checked before anything is allocated for them. Every azimuth has at least its
+	// zone count
+	if header.ElevationSegments > MaxClutterSegments {
+		return nil, fmt.Errorf("clutter filter map has %d elevation segments, more than %d", header.ElevationSegments, MaxClutterSegments)
+	}
+	if size := int64(header.ElevationSegments) * ClutterFilterAzimuths * 2; size > remaining(file) {
+		return nil, fmt.Errorf("clutter filter map has %d elevation segments, more than fit in the message", header.ElevationSegments)
+	}
+
+	m15 := ClutterFilterMap{
+		GenerationDate:    header.GenerationDate,
+		GenerationTime:    header.GenerationTime,
+		ElevationSegments: make([]ClutterFilterSegment, header.ElevationSegments),
+	}
+
+	for s := range m15.ElevationSegments {
+		segment := &m15.ElevationSegments[s]
+		for a := 0; a < ClutterFilterAzimuths; a++ {
+			var zones uint16
+			if err := binary.Read(file, binary.BigEndian, &zones); err != nil {
+				return nil, err
+			}
+
+			if zones > MaxRangeZones {
+				return nil, fmt.Errorf("clutter filter map azimuth %d has %d range zones, more than %d", a, zones, MaxRangeZones)
+			}
+
+			segment.Azimuths[a] = make([]RangeZone, zones)
+			if err := binary.Read(file, binary.BigEndian, segment.Azimuths[a]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &m15, nil
+}
+
+func (m ClutterFilterMap) Generated() time.Time {
+	return level2.JulianDateToTime(uint32(m.GenerationDate), uint32(m.GenerationTime)*60*1000)
+}
+
+// OpCode returns the clutter filter operation applied at the given azimuth (degrees)
+// and range (km) for the elevation segment
+func (s ClutterFilterSegment) OpCode(azimuth float32, rng float32) uint16 {
+	a := int(azimuth) % ClutterFilterAzimuths
+	if a < 0 {
+		a += ClutterFilterAzimuths
+	}
+
+	for _, zone := range s.Azimuths[a] {
+		if rng <= float32(zone.EndRange) {
+			return zone.OpCode
+		}
+	}
+
+	return ClutterBypassMap
+}
@@ -0,0 +1,156 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// Message 18 (RDA Adaptation Data) is a 9468 byte block sent over several segments.
+// Only the blocks below are decoded, each read from its byte offset in the
+// reassembled message.
+
+type AdaptationFile struct {
+	FileName          [12]byte
+	Format            [4]byte
+	Revision          [4]byte
+	Date              [12]byte
+	Time              [12]byte
+	AzimuthPositionK1 float32
+	AzimuthLatency    float32
+	ElevationK3       float32
+	ElevationLatency  float32
+	ParkAzimuth       float32
+	ParkElevation     float32
+	FuelConversion    [11]float32
+	MinShelterTemp    float32
+	MaxShelterTemp    float32
+	MinShelterACDiff  float32
+	MaxXMTRAirTemp    float32
+	MaxRadomeTemp     float32
+	MaxRadomeTempRise float32
+	Ped28VLimit       float32
+	Ped5VLimit        float32
+	Ped15VLimit       float32
+	MinGenRoomTemp    float32
+	MaxGenRoomTemp    float32
+	DAU5VLimit        float32
+	DAU15VLimit       float32
+	DAU28VLimit       float32
+	Encoder5VLimit    float32
+	Encoder5VNominal  float32
+	RPGCoLocated      [4]byte
+	SpecFilter        [4]byte
+	TPSInstalled      [4]byte
+	RMSInstalled      [4]byte
+	HVDLTestInterval  uint32
+	RPGLinkTestInt    uint32
+	MinStableUtilTime uint32
+	GenExerInterval   uint32
+	UtilSwitchReqInt  uint32
+	LowFuelLevel      float32 // %
+	ConfigChannel     uint32
+	RPGLinkType       uint32
+	RedundantConfig   uint32
+}
+
+type AdaptationCalibration struct {
+	TransmitterFrequency   uint32  // MHz
+	BaseDataTCN            float32 // dB
+	ReflectivityTOVER      float32 // dB
+	TargetHorizDBZ0LP      float32 // dBZ
+	TargetVertDBZ0LP       float32 // dBZ
+	InitialPhiDP           uint32  // degrees
+	NormalisedInitialPhiDP uint32  // degrees
+	LxLongPulse            float32 // dB
+	LxShortPulse           float32 // dB
+	Beamwidth              float32 // degrees
+	_                      float32
+	AntennaGain            float32 // dB
+}
+
+type AdaptationLocation struct {
+	LatSeconds   float32
+	LonSeconds   float32
+	_            uint32
+	LatDegrees   uint32
+	LatMinutes   uint32
+	LonDegrees   uint32
+	LonMinutes   uint32
+	LatDirection [4]byte
+	LonDirection [4]byte
+}
+
+type AdaptationAntenna struct {
+	AzimuthCorrection    float32 // degrees
+	ElevationCorrection  float32 // degrees
+	SiteName             [4]byte
+	MinElevation         int32 // BAMS, 360/65536 degrees
+	MaxElevation         int32 // BAMS, 360/65536 degrees
+	MaxAzimuthVelocity   int32 // degrees/s
+	MaxElevationVelocity int32 // degrees/s
+	GroundHeight         int32 // m above sea level
+	RadarHeight          int32 // m above ground
+}
+
+type AdaptationData struct {
+	File        AdaptationFile
+	Calibration AdaptationCalibration
+	Location    AdaptationLocation
+	Antenna     AdaptationAntenna
+}
+
+func ParseMessage18(file io.ReadSeeker) (*AdaptationData, error) {
+	m18 := AdaptationData{}
+
+	blocks := []struct {
+		offset int64
+		data   any
+	}{
+		{0, &m18.File},
+		{1092, &m18.Calibration},
+		{1288, &m18.Location},
+		{8360, &m18.Antenna},
+	}
+
+	startPos, _ := file.Seek(0, io.SeekCurrent)
+
+	for _, block := range blocks {
+		file.Seek(startPos+block.offset, io.SeekStart)
+		if err := binary.Read(file, binary.BigEndian, block.data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m18, nil
+}
+
+// SiteName returns the name the RDA is adapted for, which is the four letter site ID
+func (a AdaptationData) SiteName() string {
+	return strings.TrimRight(string(a.Antenna.SiteName[:]), "\x00 ")
+}
+
+// Lat returns the site latitude in decimal degrees
+func (a AdaptationData) Lat() float64 {
+	l := a.Location
+	lat := float64(l.LatDegrees) + float64(l.LatMinutes)/60.0 + float64(l.LatSeconds)/3600.0
+	if l.LatDirection[0] == 'S' {
+		lat = -lat
+	}
+	return lat
+}
+
+// Lon returns the site longitude in decimal degrees
+func (a AdaptationData) Lon() float64 {
+	l := a.Location
+	lon := float64(l.LonDegrees) + float64(l.LonMinutes)/60.0 + float64(l.LonSeconds)/3600.0
+	if l.LonDirection[0] == 'W' {
+		lon = -lon
+	}
+	return lon
+}
+
+// Height returns the height of the radar in metres above sea level
+func (a AdaptationData) Height() int {
+	return int(a.Antenna.GroundHeight + a.Antenna.RadarHeight)
+}
@@ -0,0 +1,32 @@
+package nexrad
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestParseMessage18(t *testing.T) {
+	data, err := ParseMessage18(bytes.NewReader(chunkMessage(t, startChunk, 18)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.SiteName() != "KHDX" {
+		t.Errorf("site name %q, want KHDX", data.SiteName())
+	}
+	if math.Abs(data.Lat()-33.077) > 0.001 || math.Abs(data.Lon()+106.120) > 0.001 || data.Height() != 1297 {
+		t.Errorf("location %v %v %d, want 33.077 -106.120 1297", data.Lat(), data.Lon(), data.Height())
+	}
+
+	calibration := data.Calibration
+	if calibration.TransmitterFrequency != 2860 {
+		t.Errorf("transmitter frequency %d, want 2860", calibration.TransmitterFrequency)
+	}
+	if calibration.Beamwidth != 0.93 || calibration.AntennaGain != 45.2 {
+		t.Errorf("beamwidth %v, antenna gain %v, want 0.93 and 45.2", calibration.Beamwidth, calibration.AntennaGain)
+	}
+	if calibration.LxShortPulse != -0.56 {
+		t.Errorf("short pulse loss %v, want -0.56", calibration.LxShortPulse)
+	}
+}
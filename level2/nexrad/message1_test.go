@@ -0,0 +1,110 @@
+package nexrad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// A legacy radial with 460 REF gates of 1 km and 920 VEL and SW gates of 250 m, the Doppler gates starting dopplerRange m out
+func legacyRadial(dopplerRange int16) []byte {
+	header := Message1Header{
+		CollectionTime:       76017000,
+		CollectionDate:       13605,
+		UnambiguousRange:     1171,
+		AzimuthAngle:         uint16(45.0/(180.0/4096.0)) << 3,
+		AzimuthNumber:        46,
+		ElevationAngle:       11 << 3,
+		ElevationNumber:      1,
+		SurveillanceRange:    0,
+		DopplerRange:         dopplerRange,
+		SurveillanceInterval: 1000,
+		DopplerInterval:      250,
+		SurveillanceGates:    460,
+		DopplerGates:         920,
+		VelocityResolution:   2,
+		VCP:                  21,
+		NyquistVelocity:      2665,
+	}
+	size := uint16(binary.Size(header))
+	header.SurveillancePointer = size
+	header.VelocityPointer = size + 460
+	header.SpectrumWidthPointer = size + 460 + 920
+
+	buf := bytes.NewBuffer([]byte{})
+	binary.Write(buf, binary.BigEndian, header)
+	for g := 0; g < 460; g++ {
+		buf.WriteByte(byte(2 + g%200))
+	}
+	for _, moment := range []int{0, 1} {
+		for g := 0; g < 920; g++ {
+			buf.WriteByte(byte(2 + (g+moment)%200))
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestParseMessage1(t *testing.T) {
+	m1, err := ParseMessage1(bytes.NewReader(legacyRadial(250)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m31 := m1.ToMessage31("KTLX")
+
+	if string(m31.Header.ICAO[:]) != "KTLX" || m31.Header.AzimuthAngle != 45 || m31.Header.ElevationNumber != 1 || m31.Header.AzimuthResolution != 2 {
+		t.Errorf("header %+v", m31.Header)
+	}
+	if m31.VolumeData.VCP != 21 || m31.RadialData.Velocity != 2665 || m31.RadialData.Range != 1171 {
+		t.Errorf("VCP %d, Nyquist %d, unambiguous range %d", m31.VolumeData.VCP, m31.RadialData.Velocity, m31.RadialData.Range)
+	}
+
+	tests := []struct {
+		name     string
+		gates    uint16
+		rng      uint16
+		interval uint16
+	}{
+		{"REF", 460, 0, 1000},
+		{"VEL", 920, 250, 250},
+		{"SW ", 920, 250, 250},
+	}
+	for _, test := range tests {
+		m, ok := m31.MomentData[test.name]
+		if !ok {
+			t.Fatalf("no %s", test.name)
+		}
+		if m.NumberGates != test.gates || m.Range != test.rng || m.RangeSampleInterval != test.interval {
+			t.Errorf("%s has %d gates from %d m every %d m, want %d from %d every %d", test.name, m.NumberGates, m.Range, m.RangeSampleInterval, test.gates, test.rng, test.interval)
+		}
+	}
+
+	// Gate word 12 is (12 - 66) / 2 dBZ
+	if v := m31.MomentData["REF"].Value(10); v != -27 {
+		t.Errorf("REF gate 10 is %v, want -27", v)
+	}
+	// At 0.5 m/s resolution word 13 is (13 - 129) / 2 m/s
+	if v := m31.MomentData["VEL"].Value(11); v != -58 {
+		t.Errorf("VEL gate 11 is %v, want -58", v)
+	}
+}
+
+func TestParseMessage1NegativeRange(t *testing.T) {
+	m1, err := ParseMessage1(bytes.NewReader(legacyRadial(-625)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The three gates behind the radar are dropped, so the first is 125 m out
+	for _, name := range []string{"VEL", "SW "} {
+		m := m1.MomentData[name]
+		if m.NumberGates != 917 || m.Range != 125 || m.Len() != 917 {
+			t.Errorf("%s has %d gates from %d m, want 917 from 125", name, m.NumberGates, m.Range)
+		}
+	}
+	if v := m1.MomentData["VEL"].Value(0); v != (5-129)/2.0 {
+		t.Errorf("first VEL gate is %v, want the fourth gate sent", v)
+	}
+	if m := m1.MomentData["REF"]; m.NumberGates != 460 || m.Range != 0 {
+		t.Errorf("REF has %d gates from %d m", m.NumberGates, m.Range)
+	}
+}
@@ -0,0 +1,172 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// RDAStatus is the body of Message 2 (RDA Status Data)
+type RDAStatus struct {
+	Status                        uint16
+	OperabilityStatus             uint16
+	ControlStatus                 uint16
+	AuxPowerGeneratorState        uint16
+	AverageTXPower                uint16
+	HorizRefCalibrationCorrection int16
+	DataTransmissionEnabled       uint16
+	VCP                           int16 // Negative when the VCP is a local pattern
+	ControlAuthorization          uint16
+	BuildNumber                   uint16
+	OperationalMode               uint16
+	SuperResStatus                uint16
+	ClutterMitigationStatus       uint16
+	ScanDataFlags                 uint16
+	AlarmSummary                  uint16
+	CommandAcknowledgement        uint16
+	ChannelControlStatus          uint16
+	SpotBlankingStatus            uint16
+	BypassMapDate                 uint16
+	BypassMapTime                 uint16
+	ClutterFilterMapDate          uint16
+	ClutterFilterMapTime          uint16
+	VertRefCalibrationCorrection  int16
+	TPSStatus                     uint16
+	RMSControlStatus              uint16
+	PerformanceCheckStatus        uint16
+	AlarmCodes                    [14]uint16
+	SignalProcessingOptions       uint16
+	_                             [18]uint16
+	StatusVersion                 uint16
+}
+
+var rdaStates = map[uint16]string{
+	2:  "Start-Up",
+	4:  "Standby",
+	8:  "Restart",
+	16: "Operate",
+}
+
+var rdaOperability = map[uint16]string{
+	2:  "On-Line",
+	4:  "Maintenance Action Required",
+	8:  "Maintenance Action Mandatory",
+	16: "Commanded Shut Down",
+	32: "Inoperable",
+}
+
+var rdaControl = map[uint16]string{
+	2: "Local Only",
+	4: "Remote Only",
+	8: "Either",
+}
+
+var rdaModes = map[uint16]string{
+	4: "Operational",
+	8: "Maintenance",
+}
+
+var rdaAlarms = []string{
+	"Tower/Utilities",
+	"Pedestal",
+	"Transmitter",
+	"Receiver",
+	"RDA Control",
+	"Communication",
+	"Signal Processor",
+}
+
+func ParseMessage2(file io.ReadSeeker) (*RDAStatus, error) {
+	status := RDAStatus{}
+	if err := binary.Read(file, binary.BigEndian, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func lookup(m map[uint16]string, v uint16) string {
+	if s, ok := m[v]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+func (s RDAStatus) State() string {
+	return lookup(rdaStates, s.Status)
+}
+
+func (s RDAStatus) Operability() string {
+	return lookup(rdaOperability, s.OperabilityStatus)
+}
+
+func (s RDAStatus) Control() string {
+	return lookup(rdaControl, s.ControlStatus)
+}
+
+func (s RDAStatus) Mode() string {
+	return lookup(rdaModes, s.OperationalMode)
+}
+
+func (s RDAStatus) OnAuxiliaryPower() bool {
+	return s.AuxPowerGeneratorState&1 != 0
+}
+
+func (s RDAStatus) UtilityPowerAvailable() bool {
+	return s.AuxPowerGeneratorState&2 != 0
+}
+
+func (s RDAStatus) GeneratorOn() bool {
+	return s.AuxPowerGeneratorState&4 != 0
+}
+
+func (s RDAStatus) SuperResEnabled() bool {
+	return s.SuperResStatus == 2
+}
+
+// Build returns the RDA software build, e.g. 22.0
+func (s RDAStatus) Build() float32 {
+	return float32(s.BuildNumber) / 100.0
+}
+
+// HorizCalibration returns the horizontal reflectivity calibration correction in dB
+func (s RDAStatus) HorizCalibration() float32 {
+	return float32(s.HorizRefCalibrationCorrection) / 100.0
+}
+
+// VertCalibration returns the vertical reflectivity calibration correction in dB
+func (s RDAStatus) VertCalibration() float32 {
+	return float32(s.VertRefCalibrationCorrection) / 100.0
+}
+
+// ActiveAlarms returns the names of the subsystems flagged in the alarm summary
+func (s RDAStatus) ActiveAlarms() []string {
+	alarms := []string{}
+	for i, name := range rdaAlarms {
+		if s.AlarmSummary&(1<<(i+1)) != 0 {
+			alarms = append(alarms, name)
+		}
+	}
+	return alarms
+}
+
+// Alarms returns the non-zero alarm codes reported by the RDA
+func (s RDAStatus) Alarms() []uint16 {
+	codes := []uint16{}
+	for _, c := range s.AlarmCodes {
+		if c != 0 {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+func (s RDAStatus) BypassMapGenerated() time.Time {
+	return level2.JulianDateToTime(uint32(s.BypassMapDate), uint32(s.BypassMapTime)*60*1000)
+}
+
+func (s RDAStatus) ClutterFilterMapGenerated() time.Time {
+	return level2.JulianDateToTime(uint32(s.ClutterFilterMapDate), uint32(s.ClutterFilterMapTime)*60*1000)
+}
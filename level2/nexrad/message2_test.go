@@ -0,0 +1,34 @@
+package nexrad
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseMessage2(t *testing.T) {
+	status, err := ParseMessage2(bytes.NewReader(chunkMessage(t, startChunk, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.State() != "Operate" || status.Operability() != "Maintenance Action Required" || status.Control() != "Remote Only" || status.Mode() != "Operational" {
+		t.Errorf("status %s, %s, %s, %s", status.State(), status.Operability(), status.Control(), status.Mode())
+	}
+	if status.VCP != 215 || status.Build() != 22 || !status.SuperResEnabled() || status.StatusVersion != 10 {
+		t.Errorf("VCP %d, build %v, super res %v, version %d", status.VCP, status.Build(), status.SuperResEnabled(), status.StatusVersion)
+	}
+	if alarms := status.ActiveAlarms(); len(alarms) != 1 || alarms[0] != "RDA Control" {
+		t.Errorf("alarms %v", alarms)
+	}
+	if c := status.HorizCalibration(); c < 0.225 || c > 0.235 {
+		t.Errorf("horizontal calibration %v, want 0.23", c)
+	}
+
+	if got, want := status.BypassMapGenerated(), time.Date(2024, 4, 1, 21, 41, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("bypass map generated %v, want %v", got, want)
+	}
+	if got, want := status.ClutterFilterMapGenerated(), time.Date(2023, 12, 29, 17, 23, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("clutter filter map generated %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,331 @@
+package nexrad
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Message 3 (Performance/Maintenance Data). Halfword positions follow the
+// RDA/RPG ICD and each block begins where the ICD starts the matching section.
+
+type CommunicationsStatus struct {
+	_                            uint16
+	LoopBackTestStatus           uint16
+	T1OutputFrames               uint32
+	T1InputFrames                uint32
+	RouterMemoryUsed             uint32
+	RouterMemoryFree             uint32
+	RouterMemoryUtilization      uint16
+	RouteToRPG                   uint16
+	T1PortStatus                 uint16
+	RouterDedicatedEthernetPort  uint16
+	RouterCommercialEthernetPort uint16
+	_                            [6]uint16
+	CSUErroredSeconds            uint32
+	CSUSeverelyErroredSeconds    uint32
+	CSUSeverelyErroredFraming    uint32
+	CSUUnavailableSeconds        uint32
+	CSUControlledSlipSeconds     uint32
+	CSUPathCodingViolations      uint32
+	CSULineErroredSeconds        uint32
+	CSUBurstyErroredSeconds      uint32
+	CSUDegradedMinutes           uint32
+	_                            [2]uint16
+	LANSwitchCPUUtilization      uint16
+	LANSwitchMemoryUtilization   uint16
+	_                            uint16
+	IFDRChassisTemperature       int16
+	IFDRFPGATemperature          int16
+	_                            [2]uint16
+	GPSSatellites                uint32
+	_                            [2]uint16
+	IPCStatus                    uint16
+	CommandedChannelControl      uint16
+	_                            [3]uint16
+}
+
+type AMEStatus struct {
+	Polarization                 uint16
+	InternalTemperature          float32
+	ReceiverModuleTemperature    float32
+	BITECalModuleTemperature     float32
+	PeltierPulseWidthModulation  uint16
+	PeltierStatus                uint16
+	ADConverterStatus            uint16
+	State                        uint16
+	PS3_3V                       float32
+	PS5V                         float32
+	PS6_5V                       float32
+	PS15V                        float32
+	PS48V                        float32
+	STALOPower                   float32
+	PeltierCurrent               float32
+	ADCCalibrationRefVoltage     float32
+	Mode                         uint16
+	PeltierMode                  uint16
+	PeltierInsideFanCurrent      float32
+	PeltierOutsideFanCurrent     float32
+	HorizTRLimiterVoltage        float32
+	VertTRLimiterVoltage         float32
+	ADCCalibrationOffsetVoltage  float32
+	ADCCalibrationGainCorrection float32
+}
+
+type PowerStatus struct {
+	RCPStatus                       uint16
+	RCPString                       [16]byte
+	SPIPPowerButtons                uint16
+	_                               [2]uint16
+	MasterPowerAdministratorLoad    float32
+	ExpansionPowerAdministratorLoad float32
+	_                               [22]uint16
+}
+
+type TransmitterStatus struct {
+	PS5VDC                     uint16
+	PS15VDC                    uint16
+	PS28VDC                    uint16
+	PSNeg15VDC                 uint16
+	PS45VDC                    uint16
+	FilamentPSVoltage          uint16
+	VacuumPumpPSVoltage        uint16
+	FocusCoilPSVoltage         uint16
+	FilamentPS                 uint16
+	KlystronWarmup             uint16
+	TransmitterAvailable       uint16
+	WGSwitchPosition           uint16
+	WGPFNTransferInterlock     uint16
+	MaintenanceMode            uint16
+	MaintenanceRequired        uint16
+	PFNSwitchPosition          uint16
+	ModulatorOverload          uint16
+	ModulatorInvCurrent        uint16
+	ModulatorSwitchFail        uint16
+	MainPowerVoltage           uint16
+	ChargingSystemFail         uint16
+	InverseDiodeCurrent        uint16
+	TriggerAmplifier           uint16
+	CirculatorTemperature      uint16
+	SpectrumFilterPressure     uint16
+	WGArcVSWR                  uint16
+	CabinetInterlock           uint16
+	CabinetAirTemperature      uint16
+	CabinetAirflow             uint16
+	KlystronCurrent            uint16
+	KlystronFilamentCurrent    uint16
+	KlystronVacionCurrent      uint16
+	KlystronAirTemperature     uint16
+	KlystronAirflow            uint16
+	ModulatorSwitchMaintenance uint16
+	PostChargeRegulator        uint16
+	WGPressureHumidity         uint16
+	Overvoltage                uint16
+	Overcurrent                uint16
+	FocusCoilCurrent           uint16
+	FocusCoilAirflow           uint16
+	OilTemperature             uint16
+	PRFLimit                   uint16
+	OilLevel                   uint16
+	BatteryCharging            uint16
+	HighVoltageStatus          uint16
+	RecyclingSummary           uint16
+	Inoperable                 uint16
+	AirFilter                  uint16
+	ZeroTestBits               [8]uint16
+	OneTestBits                [8]uint16
+	SPIPInterface              uint16
+	SummaryStatus              uint16
+	_                          uint16
+	RFPower                    float32 // mW
+	HorizPeakPower             float32 // kW
+	PeakPower                  float32 // kW
+	VertPeakPower              float32 // kW
+	RFAveragePower             float32 // W
+	_                          [2]uint16
+	RecycleCount               uint32
+	ReceiverBias               float32 // dB
+	TransmitImbalance          float32 // dB
+	PowerMeterZero             float32
+	_                          [6]uint16
+}
+
+type TowerUtilitiesStatus struct {
+	ACUnit1CompressorShutOff         uint16
+	ACUnit2CompressorShutOff         uint16
+	GeneratorMaintenanceRequired     uint16
+	GeneratorBatteryVoltage          uint16
+	GeneratorEngine                  uint16
+	GeneratorVoltFrequency           uint16
+	PowerSource                      uint16
+	TransitionalPowerSource          uint16
+	GeneratorAutoRunOffSwitch        uint16
+	AircraftHazardLighting           uint16
+	DAUUART                          uint16
+	_                                [10]uint16
+	EquipmentShelterFireDetection    uint16
+	EquipmentShelterFireSmoke        uint16
+	GeneratorShelterFireSmoke        uint16
+	UtilityVoltageFrequency          uint16
+	SiteSecurityAlarm                uint16
+	SecurityEquipment                uint16
+	SecuritySystem                   uint16
+	ReceiverConnectedToAntenna       uint16
+	RadomeHatch                      uint16
+	EquipmentShelterTemperature      float32 // °C
+	OutsideAmbientTemperature        float32 // °C
+	TransmitterLeavingAirTemperature float32 // °C
+	ACUnit1DischargeAirTemperature   float32 // °C
+	GeneratorShelterTemperature      float32 // °C
+	RadomeAirTemperature             float32 // °C
+	ACUnit2DischargeAirTemperature   float32 // °C
+	SPIP15VPS                        float32
+	SPIPNeg15VPS                     float32
+	SPIP28VPSStatus                  uint16
+	_                                uint16
+	SPIP5VPS                         float32
+	GeneratorFuelLevel               uint16 // %
+	_                                [28]uint16
+}
+
+type PedestalStatus struct {
+	ElevationPosDeadLimit     uint16
+	Overvoltage150V           uint16
+	Undervoltage150V          uint16
+	ElevationServoAmpInhibit  uint16
+	ElevationServoAmpShort    uint16
+	ElevationServoAmpOvertemp uint16
+	ElevationMotorOvertemp    uint16
+	ElevationStowPin          uint16
+	ElevationHousing5VPS      uint16
+	ElevationNegDeadLimit     uint16
+	ElevationPosNormalLimit   uint16
+	ElevationNegNormalLimit   uint16
+	ElevationEncoderLight     uint16
+	ElevationGearboxOil       uint16
+	ElevationHandwheel        uint16
+	ElevationAmpPS            uint16
+	AzimuthServoAmpInhibit    uint16
+	AzimuthServoAmpShort      uint16
+	AzimuthServoAmpOvertemp   uint16
+	AzimuthMotorOvertemp      uint16
+	AzimuthStowPin            uint16
+	AzimuthHousing5VPS        uint16
+	AzimuthEncoderLight       uint16
+	AzimuthGearboxOil         uint16
+	AzimuthBullGearOil        uint16
+	AzimuthHandwheel          uint16
+	AzimuthServoAmpPS         uint16
+	Servo                     uint16
+	PedestalInterlockSwitch   uint16
+}
+
+type ReceiverStatus struct {
+	COHOClock                 uint16
+	FrequencySelectOscillator uint16
+	RFSTALO                   uint16
+	PhaseShiftedCOHO          uint16
+	PS9V                      uint16
+	PS5V                      uint16
+	PS18V                     uint16
+	PSNeg9V                   uint16
+	SingleChannelRDAIU5VPS    uint16
+	_                         uint16
+	HorizShortPulseNoise      float32 // dBm
+	HorizLongPulseNoise       float32 // dBm
+	HorizNoiseTemperature     float32 // K
+	VertShortPulseNoise       float32 // dBm
+	VertLongPulseNoise        float32 // dBm
+	VertNoiseTemperature      float32 // K
+	HorizLinearity            float32
+	HorizDynamicRange         float32 // dB
+}
+
+type CalibrationStatus struct {
+	HorizDeltaDBZ0                    float32 // dB
+	VertDeltaDBZ0                     float32 // dB
+	KDPeakMeasured                    float32 // dBm
+	_                                 [2]uint16
+	ShortPulseHorizDBZ0               float32 // dBZ
+	LongPulseHorizDBZ0                float32 // dBZ
+	VelocityProcessed                 uint16
+	WidthProcessed                    uint16
+	VelocityRFGen                     uint16
+	WidthRFGen                        uint16
+	HorizI0                           float32 // dBm
+	VertI0                            float32 // dBm
+	VertDynamicRange                  float32 // dB
+	ShortPulseVertDBZ0                float32 // dBZ
+	LongPulseVertDBZ0                 float32 // dBZ
+	_                                 [4]uint16
+	HorizPowerSense                   float32 // dBm
+	VertPowerSense                    float32 // dBm
+	ZDRBias                           float32 // dB
+	_                                 [6]uint16
+	ClutterSuppressionDelta           float32 // dB
+	ClutterSuppressionUnfilteredPower float32 // dBZ
+	ClutterSuppressionFilteredPower   float32 // dBZ
+	_                                 [10]uint16
+	VertLinearity                     float32
+	_                                 [2]uint16
+}
+
+type FileStatus struct {
+	StateFileRead              uint16
+	StateFileWrite             uint16
+	BypassMapFileRead          uint16
+	BypassMapFileWrite         uint16
+	_                          [2]uint16
+	CurrentAdaptationFileRead  uint16
+	CurrentAdaptationFileWrite uint16
+	CensorZoneFileRead         uint16
+	CensorZoneFileWrite        uint16
+	RemoteVCPFileRead          uint16
+	RemoteVCPFileWrite         uint16
+	BaselineAdaptationFileRead uint16
+	_                          uint16
+	ClutterFilterMapFileRead   uint16
+	ClutterFilterMapFileWrite  uint16
+	GeneralDiskIOError         uint16
+}
+
+type DeviceStatus struct {
+	_                    [15]uint16
+	DAUComm              uint16
+	HCIComm              uint16
+	PedestalComm         uint16
+	SignalProcessorComm  uint16
+	AMEComm              uint16
+	RMSLink              uint16
+	RPGLink              uint16
+	InterpanelLink       uint16
+	PerformanceCheckTime uint32 // Seconds since 1 Jan 1970
+	_                    [9]uint16
+	Version              uint16
+}
+
+type PerformanceData struct {
+	Communications CommunicationsStatus
+	AME            AMEStatus
+	Power          PowerStatus
+	Transmitter    TransmitterStatus
+	TowerUtilities TowerUtilitiesStatus
+	Pedestal       PedestalStatus
+	Receiver       ReceiverStatus
+	Calibration    CalibrationStatus
+	Files          FileStatus
+	Devices        DeviceStatus
+}
+
+func ParseMessage3(file io.ReadSeeker) (*PerformanceData, error) {
+	data := PerformanceData{}
+	if err := binary.Read(file, binary.BigEndian, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (p PerformanceData) PerformanceCheckTime() time.Time {
+	return time.Unix(int64(p.Devices.PerformanceCheckTime), 0).UTC()
+}
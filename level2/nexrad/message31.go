@@ -1,10 +1,10 @@
 package nexrad
 
 import (
-	"encoding/binary"
 	"fmt"
 	"io"
 
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 	geojson "github.com/paulmach/go.geojson"
 )
 
@@ -50,52 +50,12 @@ type RadialData struct {
 	CalibrationVert  float32
 }
 
-type Message31Header struct {
-	ICAO              [4]byte
-	CollectionTime    uint32
-	CollectionDate    uint16
-	AzimuthNumber     uint16
-	AzimuthAngle      float32
-	Compression       uint8
-	Spare             uint8
-	RadialLength      uint16
-	AzimuthResolution uint8 // 1 = 0.5, 2 = 1.0
-	RadialStatus      uint8
-	ElevationNumber   uint8
-	CutSectorNumber   uint8
-	ElevationAngle    float32
-	RadialBlanking    uint8
-	AzimuthIndexing   uint8
-	DataBlockCount    uint16
-	// Data block pointers
-}
-
-type GenericMoment struct {
-	DataBlockType       [1]byte
-	MomentName          [3]byte
-	Reserved            uint32
-	NumberGates         uint16
-	Range               uint16
-	RangeSampleInterval uint16
-	TOVER               uint16
-	SNRThreshold        uint16
-	ControlFlags        uint8
-	DataWordSize        uint8
-	Scale               float32
-	Offset              float32
-}
-
-type Moment struct {
-	GenericMoment
-	Data []float32
-}
-
 type Message31 struct {
-	Header        Message31Header
+	Header        level2.Message31Header
 	VolumeData    VolumeData
 	ElevationData ElevationData
 	RadialData    RadialData
-	MomentData    map[string]Moment
+	MomentData    map[string]level2.MomentBlock
 }
 
 func (m31 *Message31) ToGEOJson() *geojson.FeatureCollection {
@@ -113,79 +73,25 @@ func (m31 *Message31) ToGEOJson() *geojson.FeatureCollection {
 	return nil
 }
 
-func ParseMessage31(file io.ReadSeeker) (*Message31, error) {
-	header := Message31Header{}
-
-	startPos, _ := file.Seek(0, io.SeekCurrent)
+// The moments a NEXRAD radial can carry, in the order the encoder writes them
+var momentNames = []string{"REF", "VEL", "SW ", "ZDR", "PHI", "RHO", "CFP"}
 
-	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
-		return nil, err
-	}
+// ParseMessage31 parses a Message 31 radial, see level2.ParseRadial for the checks made
+func ParseMessage31(file io.ReadSeeker) (*Message31, error) {
+	message31 := Message31{}
 
-	message31 := Message31{
-		Header:     header,
-		MomentData: make(map[string]Moment),
+	blocks := map[string]any{
+		"VOL": &message31.VolumeData,
+		"ELV": &message31.ElevationData,
+		"RAD": &message31.RadialData,
 	}
-
-	blockPointers := make([]uint32, header.DataBlockCount)
-	if err := binary.Read(file, binary.BigEndian, blockPointers); err != nil {
+	header, momentData, err := level2.ParseRadial(file, blocks, momentNames)
+	if err != nil {
 		return nil, err
-
 	}
 
-	for _, pointer := range blockPointers {
-		file.Seek(startPos+int64(pointer)+1, io.SeekStart)
-
-		n := make([]byte, 3)
-		if err := binary.Read(file, binary.BigEndian, &n); err != nil {
-			return nil, err
-
-		}
-
-		file.Seek(-4, io.SeekCurrent)
-
-		name := string(n)
-
-		switch name {
-		case "VOL":
-			binary.Read(file, binary.BigEndian, &message31.VolumeData)
-		case "ELV":
-			binary.Read(file, binary.BigEndian, &message31.ElevationData)
-		case "RAD":
-			binary.Read(file, binary.BigEndian, &message31.RadialData)
-		case "REF":
-			fallthrough
-		case "VEL":
-			fallthrough
-		case "CFP":
-			fallthrough
-		case "SW ":
-			fallthrough
-		case "ZDR":
-			fallthrough
-		case "PHI":
-			fallthrough
-		case "RHO":
-			m := GenericMoment{}
-			binary.Read(file, binary.BigEndian, &m)
-
-			ldm := m.NumberGates * uint16(m.DataWordSize) / 8
-			data := make([]byte, ldm)
-			binary.Read(file, binary.BigEndian, data)
-
-			converted := []float32{}
-			for _, n := range data {
-				converted = append(converted, (float32(n)-m.Offset)/m.Scale)
-			}
-
-			d := Moment{
-				GenericMoment: m,
-				Data:          converted,
-			}
-
-			message31.MomentData[name] = d
-		}
-	}
+	message31.Header = *header
+	message31.MomentData = momentData
 
 	return &message31, nil
 }
@@ -0,0 +1,81 @@
+package nexrad
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Encodes a radial and strips the CTM and message headers, leaving what ParseMessage31 reads
+func encodeRadial(t testing.TB, m31 *Message31) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer([]byte{})
+	e := encoder{}
+	if err := e.writeMessage31(buf, m31, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()[level2.CTMHeaderSize+level2.MessageHeaderSize:]
+}
+
+func TestParseMessage31(t *testing.T) {
+	m31, err := ParseMessage31(bytes.NewReader(chunkMessage(t, radialChunk, 31)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if icao := string(m31.Header.ICAO[:]); icao != "KHDX" {
+		t.Errorf("ICAO = %q, want KHDX", icao)
+	}
+	if m31.VolumeData.VCP != 215 {
+		t.Errorf("VCP = %d, want 215", m31.VolumeData.VCP)
+	}
+	if len(m31.MomentData) == 0 {
+		t.Fatal("no moments")
+	}
+	for name, m := range m31.MomentData {
+		if m.Len() != int(m.NumberGates) {
+			t.Errorf("%s has %d gates, want %d", name, m.Len(), m.NumberGates)
+		}
+	}
+}
+
+func TestParseMessage31Corrupt(t *testing.T) {
+	radial := chunkMessage(t, radialChunk, 31)
+
+	tests := map[string]func(b []byte){
+		"block count": func(b []byte) { b[30], b[31] = 0xFF, 0xFF },
+		"pointer":     func(b []byte) { b[32], b[33] = 0x7F, 0xFF },
+		"word size":   func(b []byte) { b[bytes.Index(b, []byte("REF"))+18] = 12 },
+		"length":      func(b []byte) { b[18], b[19] = 0, 10 },
+	}
+
+	for name, corrupt := range tests {
+		b := append([]byte{}, radial...)
+		corrupt(b)
+		if _, err := ParseMessage31(bytes.NewReader(b)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func FuzzParseMessage31(f *testing.F) {
+	f.Add(encodeRadial(f, syntheticRadial(1, 0, 360)))
+	f.Add(encodeRadial(f, syntheticRadial(3, 359, 360)))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		m31, err := ParseMessage31(bytes.NewReader(b))
+		if err != nil {
+			return
+		}
+		if len(m31.MomentData) > level2.MaxDataBlocks {
+			t.Errorf("%d moments", len(m31.MomentData))
+		}
+		for name, m := range m31.MomentData {
+			if m.Len() > len(b) {
+				t.Errorf("%s has %d gates from %d bytes", name, m.Len(), len(b))
+			}
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package nexrad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestParseMessage3(t *testing.T) {
+	// The ICD gives Message 3 as 480 halfwords
+	if size := binary.Size(PerformanceData{}); size != 960 {
+		t.Fatalf("PerformanceData is %d bytes, want 960", size)
+	}
+
+	data, err := ParseMessage3(bytes.NewReader(chunkMessage(t, startChunk, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := data.PerformanceCheckTime(), time.Date(2024, 4, 2, 0, 37, 16, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("performance check time %v, want %v", got, want)
+	}
+	if data.Devices.Version != 25 || data.TowerUtilities.GeneratorFuelLevel != 73 {
+		t.Errorf("version %d, fuel level %d", data.Devices.Version, data.TowerUtilities.GeneratorFuelLevel)
+	}
+	if data.Calibration.LongPulseHorizDBZ0 != -54.57 || data.Calibration.LongPulseVertDBZ0 != -54.94 {
+		t.Errorf("long pulse dBZ0 %v %v", data.Calibration.LongPulseHorizDBZ0, data.Calibration.LongPulseVertDBZ0)
+	}
+	if n := data.Receiver.HorizLongPulseNoise; n != -87 {
+		t.Errorf("horizontal long pulse noise %v, want -87", n)
+	}
+	if p := data.Transmitter.PeakPower; p < 697 || p > 698 {
+		t.Errorf("peak power %v, want 697.4", p)
+	}
+}
@@ -1,7 +1,8 @@
 package nexrad
 
 import (
-	"encoding/binary"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 
@@ -16,95 +17,139 @@ type Nexrad struct {
 	IsArchive      bool
 	ICAO           string
 	VolumeHeader   level2.VolumeHeader
-	VCP            *Message5
+	RDAStatus      *RDAStatus
+	Performance    *PerformanceData
+	BypassMap      *BypassMap
+	ClutterMap     *ClutterFilterMap
+	Adaptation     *AdaptationData
+	VCP            *level2.Message5
 	ElevationScans map[int]*ElevationMessages
 }
 
 func ParseNexrad(file io.ReadSeeker) (*Nexrad, error) {
-	// Start at the beginning of the file
-	file.Seek(0, io.SeekStart)
+	return ParseNexradContext(context.Background(), file, level2.DefaultLimits)
+}
+
+// ParseNexradContext parses the file, giving up once ctx is cancelled or a record breaks the limits
+func ParseNexradContext(ctx context.Context, file io.ReadSeeker, limits level2.Limits) (*Nexrad, error) {
+	return ParseNexradConcurrent(ctx, file, limits, 1)
+}
+
+/*
+ParseNexradConcurrent is ParseNexradContext with up to workers LDM records decompressed at once,
+which is much faster for full archives. Messages are still read in file order
+*/
+func ParseNexradConcurrent(ctx context.Context, file io.ReadSeeker, limits level2.Limits, workers int) (*Nexrad, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// Parse the Volume Header
-	header, err := level2.GetVolumeHeader(file)
+	decoder, err := NewDecoderContext(ctx, file, limits)
 	if err != nil {
 		return nil, err
 	}
+	decoder.Workers = workers
 
 	radar := Nexrad{
-		VolumeHeader:   *header,
-		IsArchive:      string(header.Tape[:]) == "AR2V0006.",
+		VolumeHeader:   decoder.VolumeHeader,
+		IsArchive:      decoder.IsArchive,
 		ElevationScans: make(map[int]*ElevationMessages),
 	}
 
-	// If the file is an archive file then the ICAO is provided for us already
-	if radar.IsArchive {
-		radar.ICAO = string(radar.VolumeHeader.ICAO[:])
-	} else {
-		file.Seek(0, io.SeekStart)
-	}
-
-	//fmt.Println(string(header.ICAO[:]))
-
 	for {
-
-		// Create the LDM Record
-		ldmRecord := level2.LDM{}
-		if err := binary.Read(file, binary.BigEndian, &ldmRecord.Size); err != nil {
+		message, err := decoder.Next()
+		if err != nil {
 			if err != io.EOF {
-				return nil, fmt.Errorf("reached EOF when reading LDM record")
+				return nil, err
 			}
 			break
 		}
 
-		if ldmRecord.Size < 0 {
-			ldmRecord.Size = -ldmRecord.Size
+		switch data := message.Data.(type) {
+		case *Message1:
+			radar.addRadial(data.ToMessage31(decoder.ICAO))
+		case *RDAStatus:
+			radar.RDAStatus = data
+		case *PerformanceData:
+			radar.Performance = data
+		case *level2.Message5:
+			radar.VCP = data
+		case *Message31:
+			radar.addRadial(data)
+		case *BypassMap:
+			radar.BypassMap = data
+		case *ClutterFilterMap:
+			radar.ClutterMap = data
+		case *AdaptationData:
+			radar.Adaptation = data
 		}
+	}
+
+	radar.ICAO = decoder.ICAO
 
-		// Decompress the LDM Record
-		if level2.IsCompressed(file) {
-			ldmRecord.Data = level2.Decompress(file, int(ldmRecord.Size))
-		} else {
-			ldmRecord.Data = file
+	return &radar, nil
+}
+
+func (radar *Nexrad) addRadial(m31 *Message31) {
+	elevation := int(m31.Header.ElevationNumber)
+	if radar.ElevationScans[elevation] == nil {
+		radar.ElevationScans[elevation] = &ElevationMessages{
+			M31: []*Message31{},
 		}
+	}
+	radar.ElevationScans[elevation].M31 = append(radar.ElevationScans[elevation].M31, m31)
+}
 
-		for {
-			ldmRecord.Data.Seek(level2.CTMHeaderSize, io.SeekCurrent)
+type segmentedMessage struct {
+	count    int
+	segments map[int][]byte
+}
 
-			messageHeader := level2.MessageHeader{}
-			if err := binary.Read(ldmRecord.Data, binary.BigEndian, &messageHeader); err != nil {
-				if err != io.EOF {
-					return nil, err
-				}
-				break
-			}
+/*
+Reads one segment of a multi-segment message from the body of its frame. Once every segment has been
+read the reassembled message body is returned, otherwise the result is nil
+*/
+func readSegment(file io.ReadSeeker, header level2.MessageHeader, segments map[uint8]*segmentedMessage) (io.ReadSeeker, error) {
+	size := int(header.Size)*2 - level2.MessageHeaderSize
+	if size < 0 {
+		return nil, fmt.Errorf("message %d segment %d has an invalid size of %d", header.MessageType, header.SegmentNumber, header.Size)
+	}
 
-			switch messageHeader.MessageType {
-			case 5:
-				radar.VCP, err = ParseMessage5(ldmRecord.Data)
-				if err != nil {
-					return nil, err
-				}
-				ldmRecord.Data.Seek(int64(level2.DefaultMessageSize-(messageHeader.Size*2)-level2.CTMHeaderSize), io.SeekCurrent)
-			case 31:
-				m31, err := ParseMessage31(ldmRecord.Data)
-				if err != nil {
-					return nil, err
-				}
-				if radar.ICAO == "" {
-					radar.ICAO = string(m31.Header.ICAO[:])
-				}
-				if radar.ElevationScans[int(m31.Header.ElevationNumber)] == nil {
-					radar.ElevationScans[int(m31.Header.ElevationNumber)] = &ElevationMessages{
-						M31: []*Message31{},
-					}
-				}
-				radar.ElevationScans[int(m31.Header.ElevationNumber)].M31 = append(radar.ElevationScans[int(m31.Header.ElevationNumber)].M31, m31)
-			default:
-				ldmRecord.Data.Seek(int64(level2.MessageBodySize), io.SeekCurrent)
-			}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, err
+	}
+
+	message := segments[header.MessageType]
+	if message == nil || message.count != int(header.Segments) {
+		message = &segmentedMessage{
+			count:    int(header.Segments),
+			segments: make(map[int][]byte),
 		}
+		segments[header.MessageType] = message
+	}
+	message.segments[int(header.SegmentNumber)] = data
 
+	if len(message.segments) < message.count {
+		return nil, nil
 	}
 
-	return &radar, nil
+	body := []byte{}
+	for i := 1; i <= message.count; i++ {
+		segment, ok := message.segments[i]
+		if !ok {
+			return nil, fmt.Errorf("message %d is missing segment %d", header.MessageType, i)
+		}
+		body = append(body, segment...)
+	}
+	delete(segments, header.MessageType)
+
+	return bytes.NewReader(body), nil
+}
+
+// Returns how many bytes are left to read in a message body
+func remaining(file io.ReadSeeker) int64 {
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	end, _ := file.Seek(0, io.SeekEnd)
+	file.Seek(offset, io.SeekStart)
+	return end - offset
 }
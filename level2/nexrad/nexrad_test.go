@@ -0,0 +1,67 @@
+package nexrad
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Limits small enough that fuzzed sizes can't exhaust memory
+var fuzzLimits = level2.Limits{
+	MaxRecordSize:       1 << 20,
+	MaxDecompressedSize: 4 << 20,
+	MaxVolumeSize:       16 << 20,
+}
+
+func TestParseNexradTruncated(t *testing.T) {
+	archive := readChunks(t, startChunk, radialChunk)
+
+	for _, size := range []int{10, level2.FileHeaderSize + 2, len(archive) / 2, len(archive) - 1} {
+		_, err := ParseNexrad(bytes.NewReader(archive[:size]))
+		if err == nil {
+			t.Errorf("%d bytes: no error", size)
+			continue
+		}
+		if !errors.Is(err, level2.ErrTruncatedRecord) && !errors.Is(err, level2.ErrBadCompression) && !errors.Is(err, level2.ErrUnknownFormat) {
+			t.Errorf("%d bytes: %v", size, err)
+		}
+	}
+}
+
+func TestParseNexradSizeLimit(t *testing.T) {
+	archive := readChunks(t, startChunk)
+
+	_, err := ParseNexradContext(context.Background(), bytes.NewReader(archive), level2.Limits{MaxDecompressedSize: 100})
+	var e *level2.Error
+	if !errors.As(err, &e) || !errors.Is(err, level2.ErrSizeLimit) || e.Offset != level2.FileHeaderSize {
+		t.Errorf("err = %v, want a size limit error at %d", err, level2.FileHeaderSize)
+	}
+}
+
+func FuzzParseNexrad(f *testing.F) {
+	f.Add(syntheticArchive(f, 1, 12, EncodeOptions{RadialsPerRecord: 4}))
+	f.Add(syntheticArchive(f, 2, 8, EncodeOptions{}))
+
+	// Chunks from the live feed are used as well when they are checked out
+	chunks, _ := filepath.Glob("../../test/chunks/*")
+	for _, chunk := range chunks {
+		if b, err := os.ReadFile(chunk); err == nil {
+			f.Add(b)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, err := ParseNexradContext(context.Background(), bytes.NewReader(b), fuzzLimits)
+		if err != nil {
+			var e *level2.Error
+			if !errors.As(err, &e) {
+				t.Errorf("untyped error %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package nexrad
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Builds a radial with 8 bit REF and 16 bit VEL so that tests don't need archives from the feed
+func syntheticRadial(elevation int, azimuth int, radials int) *Message31 {
+	status := level2.IntermediateRadial
+	switch {
+	case azimuth == 0 && elevation == 1:
+		status = level2.StartOfVolume
+	case azimuth == 0:
+		status = level2.StartOfElevation
+	case azimuth == radials-1:
+		status = level2.EndOfElevation
+	}
+
+	m31 := Message31{
+		Header: level2.Message31Header{
+			ICAO:              [4]byte{'K', 'T', 'S', 'T'},
+			CollectionTime:    uint32(60000 + elevation*30000 + azimuth*100),
+			CollectionDate:    19815,
+			AzimuthNumber:     uint16(azimuth + 1),
+			AzimuthAngle:      float32(azimuth) * 360 / float32(radials),
+			AzimuthResolution: 2,
+			RadialStatus:      uint8(status),
+			ElevationNumber:   uint8(elevation),
+			CutSectorNumber:   1,
+			ElevationAngle:    float32(elevation) * 0.5,
+		},
+		VolumeData: VolumeData{
+			DataBlockType: [1]byte{'R'},
+			DataName:      [3]byte{'V', 'O', 'L'},
+			LRTUP:         44,
+			Lat:           35.3,
+			Long:          -97.5,
+			Height:        370,
+			VCP:           212,
+		},
+		ElevationData: ElevationData{
+			DataBlockType: [1]byte{'R'},
+			DataName:      [3]byte{'E', 'L', 'V'},
+			LRTUP:         12,
+		},
+		RadialData: RadialData{
+			DataBlockType: [1]byte{'R'},
+			DataName:      [3]byte{'R', 'A', 'D'},
+			LRTUP:         28,
+			Range:         4600,
+			Velocity:      2870,
+		},
+		MomentData: map[string]level2.MomentBlock{},
+	}
+
+	ref := make([]byte, 100)
+	for i := range ref {
+		ref[i] = byte(i + azimuth)
+	}
+	m31.MomentData["REF"] = syntheticMoment("REF", 8, 2, 66, ref)
+
+	vel := make([]byte, 2*60)
+	for i := 0; i < len(vel); i += 2 {
+		vel[i], vel[i+1] = byte(azimuth), byte(i)
+	}
+	m31.MomentData["VEL"] = syntheticMoment("VEL", 16, 2, 129, vel)
+
+	return &m31
+}
+
+func syntheticMoment(name string, wordSize uint8, scale float32, offset float32, raw []byte) level2.MomentBlock {
+	m := level2.GenericMoment{
+		DataBlockType:       [1]byte{'D'},
+		MomentName:          [3]byte{name[0], name[1], name[2]},
+		NumberGates:         uint16(len(raw) * 8 / int(wordSize)),
+		Range:               2125,
+		RangeSampleInterval: 250,
+		DataWordSize:        wordSize,
+		Scale:               scale,
+		Offset:              offset,
+	}
+	return level2.MomentBlock{
+		GenericMoment: m,
+		Gates:         level2.NewGates(wordSize, scale, offset, raw),
+	}
+}
+
+// Builds a radar with the given number of elevations and radials in each
+func syntheticRadar(elevations int, radials int) *Nexrad {
+	radar := Nexrad{
+		ICAO:           "KTST",
+		ElevationScans: map[int]*ElevationMessages{},
+		VCP: &level2.Message5{
+			Header: level2.Message5Header{
+				MessageSize:   uint16(11 + 23*elevations),
+				PatternType:   2,
+				PatternNumber: 212,
+				NumberOfCuts:  uint16(elevations),
+			},
+		},
+	}
+
+	for e := 1; e <= elevations; e++ {
+		radar.VCP.ElevationAngles = append(radar.VCP.ElevationAngles, level2.ElevationCut{
+			ElevationAngle: level2.Angle(e * 0x5B),
+			Waveform:       level2.WaveformCS,
+		})
+		for a := 0; a < radials; a++ {
+			radar.addRadial(syntheticRadial(e, a, radials))
+		}
+	}
+
+	return &radar
+}
+
+// Encodes a synthetic radar to an archive
+func syntheticArchive(t testing.TB, elevations int, radials int, opts EncodeOptions) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := Encode(buf, syntheticRadar(elevations, radials), opts); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
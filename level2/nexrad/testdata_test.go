@@ -0,0 +1,84 @@
+package nexrad
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+)
+
+// The first chunk of a KHDX volume from the live feed, which carries the metadata messages
+const startChunk = "../../test/chunks/20240401-214657-001-S"
+
+// The chunk after it, which starts with the first radials of the volume
+const radialChunk = "../../test/chunks/20240401-214657-002-I"
+
+// Reads the given chunks and joins them into one archive. The test is skipped when they are not checked out
+func readChunks(t testing.TB, chunks ...string) []byte {
+	t.Helper()
+
+	archive := []byte{}
+	for _, chunk := range chunks {
+		b, err := os.ReadFile(chunk)
+		if os.IsNotExist(err) {
+			t.Skipf("%s is not checked out", chunk)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		archive = append(archive, b...)
+	}
+	return archive
+}
+
+/*
+Returns the body of the first message of the given type in the first LDM record of a chunk, with
+the segments of a multi-segment message joined. The test is skipped when the chunk is not checked out
+*/
+func chunkMessage(t testing.TB, chunk string, messageType uint8) []byte {
+	t.Helper()
+
+	b := readChunks(t, chunk)
+
+	// Only the first chunk of a volume has the volume header
+	if bytes.HasPrefix(b, []byte("AR2V")) {
+		b = b[24:]
+	}
+
+	// Skip the LDM record size
+	record, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(b[4:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte{}
+	for offset := 0; offset+12+16 <= len(record); {
+		header := record[offset+12 : offset+12+16]
+		size := int(binary.BigEndian.Uint16(header[0:2]))*2 - 16
+		if size < 0 {
+			size = 0
+		}
+		segments := int(binary.BigEndian.Uint16(header[12:14]))
+		segment := int(binary.BigEndian.Uint16(header[14:16]))
+
+		start := offset + 12 + 16
+		if header[3] == messageType && start+size <= len(record) {
+			body = append(body, record[start:start+size]...)
+			if segment >= segments {
+				return body
+			}
+		}
+
+		// Every message but 31 takes up a whole frame
+		if header[3] == 31 {
+			offset = start + size
+		} else {
+			offset += 2432
+		}
+	}
+
+	t.Fatalf("no message %d in %s", messageType, chunk)
+	return nil
+}
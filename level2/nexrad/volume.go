@@ -0,0 +1,67 @@
+package nexrad
+
+import (
+	"sort"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Ray converts the radial to the format-agnostic model
+func (m31 *Message31) Ray() *level2.Ray {
+	return m31.Header.Ray(float32(m31.RadialData.Velocity)/100.0, float32(m31.RadialData.Range)/10.0, m31.MomentData)
+}
+
+/*
+Volume converts the parsed file to the format-agnostic model. The site comes from the adaptation data
+when it was sent, otherwise from the volume block of the radials
+*/
+func (radar *Nexrad) Volume() *level2.Volume {
+	volume := level2.Volume{
+		ICAO:   radar.ICAO,
+		Sweeps: []*level2.Sweep{},
+	}
+
+	if radar.IsArchive {
+		volume.Time = radar.VolumeHeader.Date()
+	}
+
+	if radar.VCP != nil {
+		volume.VCP = int(radar.VCP.Header.PatternNumber)
+	}
+
+	if radar.Adaptation != nil {
+		volume.Site = &level2.Site{
+			Name:   radar.Adaptation.SiteName(),
+			Lat:    radar.Adaptation.Lat(),
+			Lon:    radar.Adaptation.Lon(),
+			Height: float64(radar.Adaptation.Height()),
+		}
+	}
+
+	elevations := make([]int, 0, len(radar.ElevationScans))
+	for k := range radar.ElevationScans {
+		elevations = append(elevations, k)
+	}
+	sort.Ints(elevations)
+
+	for _, elevation := range elevations {
+		for _, m31 := range radar.ElevationScans[elevation].M31 {
+			if volume.VCP == 0 {
+				volume.VCP = int(m31.VolumeData.VCP)
+			}
+			if volume.Site == nil && (m31.VolumeData.Lat != 0 || m31.VolumeData.Long != 0) {
+				volume.Site = &level2.Site{
+					Lat:    float64(m31.VolumeData.Lat),
+					Lon:    float64(m31.VolumeData.Long),
+					Height: float64(m31.VolumeData.Height) + float64(m31.VolumeData.FeedhornHeight),
+				}
+			}
+
+			volume.AddRadial(&m31.Header, m31.Ray())
+		}
+	}
+
+	volume.TagSupplemental()
+
+	return &volume
+}
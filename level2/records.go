@@ -0,0 +1,122 @@
+package level2
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+)
+
+// Record is a decompressed LDM record. Offset is the position of the record in the file
+type Record struct {
+	Offset int64
+	Data   *bytes.Reader
+	Err    error
+}
+
+/*
+DecompressRecords reads the compressed LDM records from the current position to the end of the file
+and decompresses up to workers of them at once. Records are sent on the returned channel in file
+order, which is closed after the last record or the first error. The caller must read until the
+channel is closed or cancel ctx, otherwise the reading goroutines are left blocked
+*/
+func DecompressRecords(ctx context.Context, file io.ReadSeeker, limits Limits, workers int) <-chan Record {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Stops reading ahead once a record fails
+	ctx, cancel := context.WithCancel(ctx)
+
+	out := make(chan Record)
+	// Each pending record gets its own channel so that they can be handed on in order
+	pending := make(chan chan Record, workers)
+	sem := make(chan struct{}, workers)
+
+	go func() {
+		defer close(pending)
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			offset, _ := file.Seek(0, io.SeekCurrent)
+
+			var size int32
+			if err := binary.Read(file, binary.BigEndian, &size); err != nil {
+				if err != io.EOF {
+					pending <- failedRecord(offset, NewError(ErrTruncatedRecord, offset, err))
+				}
+				return
+			}
+			if size < 0 {
+				size = -size
+			}
+
+			if limits.MaxRecordSize > 0 && int64(size) > limits.MaxRecordSize {
+				pending <- failedRecord(offset, NewError(ErrSizeLimit, offset, nil))
+				return
+			}
+
+			compressed := make([]byte, size)
+			if _, err := io.ReadFull(file, compressed); err != nil {
+				pending <- failedRecord(offset, NewError(ErrTruncatedRecord, offset, err))
+				return
+			}
+
+			result := make(chan Record, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				result <- Record{Offset: offset, Err: ctx.Err()}
+				return
+			}
+			go func() {
+				defer func() { <-sem }()
+				data, err := DecompressWithLimit(bytes.NewReader(compressed), len(compressed), 0, limits.MaxDecompressedSize)
+				if err != nil {
+					err = RecordError(err, offset)
+				}
+				result <- Record{Offset: offset, Data: data, Err: err}
+			}()
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for result := range pending {
+			record := <-result
+			select {
+			case out <- record:
+			case <-ctx.Done():
+				record.Err = ctx.Err()
+			}
+			if record.Err == nil {
+				continue
+			}
+
+			// Stop reading ahead and wait for the reader to finish before closing
+			cancel()
+			for range pending {
+			}
+			return
+		}
+	}()
+
+	return out
+}
+
+func failedRecord(offset int64, err error) chan Record {
+	result := make(chan Record, 1)
+	result <- Record{Offset: offset, Err: err}
+	return result
+}
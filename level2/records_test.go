@@ -0,0 +1,101 @@
+package level2
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Builds a file of compressed LDM records, record i holding "record i" repeated to a varying size
+func compressedRecords(t testing.TB, n int) ([]byte, [][]byte) {
+	t.Helper()
+
+	file := bytes.NewBuffer([]byte{})
+	records := [][]byte{}
+	for i := 0; i < n; i++ {
+		record := bytes.Repeat([]byte(fmt.Sprintf("record %d ", i)), 100+(i*37)%500)
+		records = append(records, record)
+
+		compressed, err := Compress(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.Write(file, binary.BigEndian, int32(len(compressed)))
+		file.Write(compressed)
+	}
+	return file.Bytes(), records
+}
+
+func TestDecompressRecordsOrder(t *testing.T) {
+	file, want := compressedRecords(t, 50)
+
+	for _, workers := range []int{1, 3, 8} {
+		i := 0
+		var offset int64
+		for record := range DecompressRecords(context.Background(), bytes.NewReader(file), DefaultLimits, workers) {
+			if record.Err != nil {
+				t.Fatalf("%d workers: %v", workers, record.Err)
+			}
+			if record.Offset < offset {
+				t.Fatalf("%d workers: record at %d came after %d", workers, record.Offset, offset)
+			}
+			offset = record.Offset
+
+			got := make([]byte, record.Data.Len())
+			record.Data.Read(got)
+			if !bytes.Equal(got, want[i]) {
+				t.Fatalf("%d workers: record %d out of order", workers, i)
+			}
+			i++
+		}
+		if i != len(want) {
+			t.Errorf("%d workers: %d records, want %d", workers, i, len(want))
+		}
+	}
+}
+
+func TestDecompressRecordsErrors(t *testing.T) {
+	file, _ := compressedRecords(t, 10)
+
+	tests := map[string]struct {
+		file   []byte
+		limits Limits
+		kind   error
+	}{
+		"truncated":  {file[:len(file)-10], DefaultLimits, ErrTruncatedRecord},
+		"size limit": {file, Limits{MaxDecompressedSize: 100}, ErrSizeLimit},
+		"record":     {file, Limits{MaxRecordSize: 10}, ErrSizeLimit},
+	}
+
+	for name, test := range tests {
+		var err error
+		for record := range DecompressRecords(context.Background(), bytes.NewReader(test.file), test.limits, 4) {
+			err = record.Err
+		}
+		var e *Error
+		if !errors.Is(err, test.kind) || !errors.As(err, &e) {
+			t.Errorf("%s: err = %v, want %v", name, err, test.kind)
+		}
+	}
+}
+
+func TestDecompressRecordsCancel(t *testing.T) {
+	file, _ := compressedRecords(t, 50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	records := DecompressRecords(ctx, bytes.NewReader(file), DefaultLimits, 4)
+	<-records
+	cancel()
+
+	// The channel must still be closed once the workers stop
+	var err error
+	for record := range records {
+		err = record.Err
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v", err)
+	}
+}
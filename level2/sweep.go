@@ -0,0 +1,225 @@
+package level2
+
+import (
+	"math"
+	"sort"
+)
+
+// GateGeometry describes where the gates of a moment are along each ray
+type GateGeometry struct {
+	FirstGate    float32 // km
+	GateInterval float32 // km
+	Gates        int
+}
+
+// Range returns the range to gate i in km
+func (g GateGeometry) Range(i int) float32 {
+	return g.FirstGate + float32(i)*g.GateInterval
+}
+
+/*
+Sorted returns a copy of the sweep with the rays in order of azimuth. The rays themselves are shared.
+The sweep is left in the order the radar sent it as the first ray is where the scan started
+*/
+func (s *Sweep) Sorted() *Sweep {
+	sorted := *s
+	sorted.Rays = append([]*Ray{}, s.Rays...)
+	sort.SliceStable(sorted.Rays, func(i, j int) bool {
+		return sorted.Rays[i].Azimuth < sorted.Rays[j].Azimuth
+	})
+	return &sorted
+}
+
+// Complete reports whether the sweep holds every ray from the start to the end of the elevation
+func (s *Sweep) Complete() bool {
+	if len(s.Rays) == 0 {
+		return false
+	}
+	switch s.Rays[0].Status {
+	case StartOfElevation, StartOfVolume, StartOfLastElevation:
+	default:
+		return false
+	}
+	return s.Rays[len(s.Rays)-1].EndOfElevation()
+}
+
+// Moments returns the names of the moments in the sweep
+func (s *Sweep) Moments() []string {
+	names := []string{}
+	for _, ray := range s.Rays {
+		for name := range ray.Moments {
+			if !contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Geometry returns the gate geometry of a moment, taken from the longest ray that has it
+func (s *Sweep) Geometry(name string) (GateGeometry, bool) {
+	geometry := GateGeometry{}
+	found := false
+	for _, ray := range s.Rays {
+		m, ok := ray.Moments[name]
+		if !ok || (found && m.Len() <= geometry.Gates) {
+			continue
+		}
+		geometry = GateGeometry{
+			FirstGate:    m.FirstGate,
+			GateInterval: m.GateInterval,
+			Gates:        m.Len(),
+		}
+		found = true
+	}
+	return geometry, found
+}
+
+// Nearest returns the ray closest to the azimuth. The sweep must be sorted, see Sorted
+func (s *Sweep) Nearest(azimuth float32) *Ray {
+	if len(s.Rays) == 0 {
+		return nil
+	}
+
+	i := sort.Search(len(s.Rays), func(i int) bool {
+		return s.Rays[i].Azimuth >= azimuth
+	})
+
+	// The rays either side, wrapping around north
+	before := s.Rays[(i-1+len(s.Rays))%len(s.Rays)]
+	after := s.Rays[i%len(s.Rays)]
+	if azimuthDistance(before.Azimuth, azimuth) < azimuthDistance(after.Azimuth, azimuth) {
+		return before
+	}
+	return after
+}
+
+// The most two sweeps can differ in elevation and still be treated as the same tilt
+const sameAngleTolerance = 0.1
+
+func sameAngle(a float32, b float32) bool {
+	return math.Abs(float64(a-b)) <= sameAngleTolerance
+}
+
+/*
+SupplementalBase returns the number of the earlier sweep that a sweep repeats, or 0 if it is a base
+sweep. angles holds the elevation angle of the sweeps before it by number. Sweeps at the same angle
+straight after one another are a split cut and not a repeat, so SAILS and MRLE cuts are the ones
+that come back to an angle after the radar has moved up to others
+*/
+func SupplementalBase(number int, angle float32, angles map[int]float32) int {
+	base := 0
+	split := true
+	for n := number - 1; n > 0; n-- {
+		a, ok := angles[n]
+		if !ok {
+			continue
+		}
+		if !sameAngle(a, angle) {
+			split = false
+			continue
+		}
+		if !split {
+			base = n
+		}
+	}
+	return base
+}
+
+// TagSupplemental marks the sweeps that repeat a lower tilt and links them to the sweep they repeat
+func (v *Volume) TagSupplemental() {
+	angles := make(map[int]float32, len(v.Sweeps))
+	for _, sweep := range v.Sweeps {
+		sweep.Base = SupplementalBase(sweep.Number, sweep.ElevationAngle, angles)
+		sweep.Supplemental = sweep.Base != 0
+		angles[sweep.Number] = sweep.ElevationAngle
+	}
+}
+
+/*
+MergeSplitCuts returns the sweeps with each split cut merged into one logical tilt. The low tilts of
+a VCP are scanned twice at the same angle, once for surveillance (long range reflectivity) and then
+for Doppler (velocity). The Doppler moments are added to the nearest surveillance ray so that they
+live together. Only complete sweeps are merged, a chunk holding part of a split cut is left as it is.
+The volume is not changed, merged sweeps are new and keep the ray order of the surveillance sweep
+*/
+func (v *Volume) MergeSplitCuts() []*Sweep {
+	sweeps := []*Sweep{}
+
+	for i := 0; i < len(v.Sweeps); i++ {
+		sweep := v.Sweeps[i]
+		if i+1 < len(v.Sweeps) && isSplitCut(sweep, v.Sweeps[i+1]) {
+			sweeps = append(sweeps, mergeSweeps(sweep, v.Sweeps[i+1]))
+			i++
+			continue
+		}
+		sweeps = append(sweeps, sweep)
+	}
+
+	return sweeps
+}
+
+func isSplitCut(surveillance *Sweep, doppler *Sweep) bool {
+	if !surveillance.Complete() || !doppler.Complete() {
+		return false
+	}
+	if !sameAngle(surveillance.ElevationAngle, doppler.ElevationAngle) {
+		return false
+	}
+	_, survVel := surveillance.Geometry("VEL")
+	_, dopVel := doppler.Geometry("VEL")
+	return !survVel && dopVel
+}
+
+func mergeSweeps(surveillance *Sweep, doppler *Sweep) *Sweep {
+	sorted := doppler.Sorted()
+
+	merged := Sweep{
+		Number:            surveillance.Number,
+		ElevationAngle:    surveillance.ElevationAngle,
+		AzimuthResolution: surveillance.AzimuthResolution,
+		Supplemental:      surveillance.Supplemental,
+		Base:              surveillance.Base,
+		Rays:              make([]*Ray, len(surveillance.Rays)),
+	}
+
+	for i, ray := range surveillance.Rays {
+		r := *ray
+		r.Moments = make(map[string]*Moment, len(ray.Moments))
+		for name, m := range ray.Moments {
+			r.Moments[name] = m
+		}
+
+		if d := sorted.Nearest(ray.Azimuth); d != nil {
+			r.NyquistVelocity = d.NyquistVelocity
+			for name, m := range d.Moments {
+				// Surveillance moments reach further so they are kept
+				if _, ok := r.Moments[name]; !ok {
+					r.Moments[name] = m
+				}
+			}
+		}
+
+		merged.Rays[i] = &r
+	}
+
+	return &merged
+}
+
+func azimuthDistance(a float32, b float32) float32 {
+	d := float32(math.Abs(float64(a - b)))
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package level2
+
+import "testing"
+
+// Builds a sweep of one degree rays starting at the given azimuth, with the named moments on every ray
+func testSweep(number int, angle float32, start float32, rays int, moments ...string) *Sweep {
+	sweep := &Sweep{Number: number, ElevationAngle: angle, AzimuthResolution: 1}
+	for i := 0; i < rays; i++ {
+		ray := &Ray{
+			Azimuth: float32(int(start)+i) - float32(360*((int(start)+i)/360)),
+			Status:  IntermediateRadial,
+			Moments: map[string]*Moment{},
+		}
+		for _, name := range moments {
+			ray.Moments[name] = &Moment{Name: name, Gates: NewGates(8, 2, 66, []byte{10, 20})}
+		}
+		sweep.Rays = append(sweep.Rays, ray)
+	}
+	sweep.Rays[0].Status = StartOfElevation
+	sweep.Rays[rays-1].Status = EndOfElevation
+	return sweep
+}
+
+func TestSorted(t *testing.T) {
+	sweep := testSweep(1, 0.5, 300, 360, "REF")
+
+	sorted := sweep.Sorted()
+	if sweep.Rays[0].Azimuth != 300 {
+		t.Errorf("sweep was reordered, first ray at %v", sweep.Rays[0].Azimuth)
+	}
+	for i := 1; i < len(sorted.Rays); i++ {
+		if sorted.Rays[i].Azimuth < sorted.Rays[i-1].Azimuth {
+			t.Fatalf("ray %d at %v is before %v", i, sorted.Rays[i].Azimuth, sorted.Rays[i-1].Azimuth)
+		}
+	}
+
+	if ray := sorted.Nearest(359.8); ray.Azimuth != 0 {
+		t.Errorf("nearest to 359.8 = %v, want 0", ray.Azimuth)
+	}
+}
+
+func TestMergeSplitCuts(t *testing.T) {
+	volume := Volume{
+		Sweeps: []*Sweep{
+			testSweep(1, 0.5, 200, 360, "REF"),
+			testSweep(2, 0.48, 30, 360, "REF", "VEL", "SW "),
+			testSweep(3, 0.9, 0, 360, "REF"),
+		},
+	}
+
+	sweeps := volume.MergeSplitCuts()
+	if len(sweeps) != 2 || sweeps[0].Number != 1 || sweeps[1].Number != 3 {
+		t.Fatalf("got %d sweeps, want 1 and 3", len(sweeps))
+	}
+
+	merged := sweeps[0]
+	if merged.Rays[0].Azimuth != 200 {
+		t.Errorf("merged sweep starts at %v, want the surveillance start of 200", merged.Rays[0].Azimuth)
+	}
+	for _, ray := range merged.Rays {
+		if ray.Moments["VEL"] == nil || ray.Moments["SW "] == nil {
+			t.Fatalf("ray at %v is missing Doppler moments", ray.Azimuth)
+		}
+	}
+	if merged.Rays[0].Moments["REF"] != volume.Sweeps[0].Rays[0].Moments["REF"] {
+		t.Error("surveillance REF was replaced")
+	}
+	if _, ok := volume.Sweeps[0].Rays[0].Moments["VEL"]; ok {
+		t.Error("the volume was changed")
+	}
+
+	// Part of a split cut, as in a chunk, is left alone
+	volume.Sweeps[1] = testSweep(2, 0.48, 30, 120, "REF", "VEL")
+	volume.Sweeps[1].Rays[119].Status = IntermediateRadial
+	if sweeps := volume.MergeSplitCuts(); len(sweeps) != 3 {
+		t.Errorf("incomplete split cut was merged")
+	}
+}
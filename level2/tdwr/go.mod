@@ -1,6 +1,6 @@
 module github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr
 
-replace github.com/TheRangiCrew/NEXRAD-GO/level2/ => ../
+replace github.com/TheRangiCrew/NEXRAD-GO/level2 => ../
 
 go 1.22.1
 
@@ -8,3 +8,5 @@ require (
 	github.com/TheRangiCrew/NEXRAD-GO/level2 v0.0.0-20240419005628-d98dda7ac56e
 	github.com/paulmach/go.geojson v1.5.0
 )
+
+require github.com/dsnet/compress v0.0.1 // indirect
@@ -1,10 +1,10 @@
 package tdwr
 
 import (
-	"encoding/binary"
 	"fmt"
 	"io"
 
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 	geojson "github.com/paulmach/go.geojson"
 )
 
@@ -35,59 +35,45 @@ type ElevationData struct {
 	CalibrationConstant float32
 }
 
+// TDWR radials use the shorter 20 byte RAD block without the calibration constants
 type RadialData struct {
 	DataBlockType [1]byte
 	DataName      [3]byte
 	LRTUP         uint16
-	Range         uint16
+	Range         uint16 // Scaled by 10, km
+	NoiseHoriz    float32
+	NoiseVert     float32
+	Velocity      uint16 // Nyquist velocity scaled by 100, m/s. Zero in the archives seen so far, see NyquistVelocity
+	_             uint16
 }
 
-type Message31Header struct {
-	ICAO              [4]byte
-	CollectionTime    uint32
-	CollectionDate    uint16
-	AzimuthNumber     uint16
-	AzimuthAngle      float32
-	Compression       uint8
-	Spare             uint8
-	RadialLength      uint16
-	AzimuthResolution uint8 // 1 = 0.5, 2 = 1.0
-	RadialStatus      uint8
-	ElevationNumber   uint8
-	CutSectorNumber   uint8
-	ElevationAngle    float32
-	RadialBlanking    uint8
-	AzimuthIndexing   uint8
-	DataBlockCount    uint16
-	// Data block pointers
-}
+// Wavelength of the TDWR transmitter, m
+const wavelength = 0.0533
 
-type GenericMoment struct {
-	DataBlockType       [1]byte
-	MomentName          [3]byte
-	Reserved            uint32
-	NumberGates         uint16
-	Range               uint16
-	RangeSampleInterval uint16
-	TOVER               uint16
-	SNRThreshold        uint16
-	ControlFlags        uint8
-	DataWordSize        uint8
-	Scale               float32
-	Offset              float32
-}
+const speedOfLight = 299792458 // m/s
 
-type Moment struct {
-	GenericMoment
-	Data []float32
+/*
+NyquistVelocity returns the Nyquist velocity of the radial in m/s. TDWR archives leave it, and the
+noise levels, zero in the RAD block, so it is then worked out from the unambiguous range, which fixes
+the PRF the velocity was measured at. It is 0 if neither is sent
+*/
+func (r RadialData) NyquistVelocity() float32 {
+	if r.Velocity != 0 {
+		return float32(r.Velocity) / 100
+	}
+	if r.Range == 0 {
+		return 0
+	}
+	prf := speedOfLight / (2 * float64(r.Range) * 100)
+	return float32(wavelength * prf / 4)
 }
 
 type Message31 struct {
-	Header        Message31Header
+	Header        level2.Message31Header
 	VolumeData    VolumeData
 	ElevationData ElevationData
 	RadialData    RadialData
-	MomentData    map[string]Moment
+	MomentData    map[string]level2.MomentBlock
 }
 
 func (m31 *Message31) ToGEOJson() *geojson.FeatureCollection {
@@ -105,95 +91,29 @@ func (m31 *Message31) ToGEOJson() *geojson.FeatureCollection {
 	return nil
 }
 
-func ParseMessage31(file io.ReadSeeker) Message31 {
-
-	startPos, _ := file.Seek(0, io.SeekCurrent)
-
-	header := Message31Header{}
-	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
-		panic(err)
-	}
-
-	curr, _ := file.Seek(0, io.SeekCurrent)
-	fmt.Printf("After header: %d\n", curr)
-
-	message31 := Message31{
-		Header:     header,
-		MomentData: make(map[string]Moment),
-	}
-
-	blockPointers := make([]uint32, header.DataBlockCount)
-	if err := binary.Read(file, binary.BigEndian, blockPointers); err != nil {
-		panic(err.Error())
+// The moments a TDWR radial can carry
+var momentNames = []string{"REF", "VEL", "SW "}
+
+/*
+ParseMessage31 parses a TDWR radial, see level2.ParseRadial for the checks made. The reader should
+only hold the message body so that pointers past the end of the radial fail instead of reading
+into the next message
+*/
+func ParseMessage31(file io.ReadSeeker) (*Message31, error) {
+	message31 := Message31{}
+
+	blocks := map[string]any{
+		"VOL": &message31.VolumeData,
+		"ELV": &message31.ElevationData,
+		"RAD": &message31.RadialData,
 	}
-	fmt.Println(blockPointers)
-
-	curr, _ = file.Seek(0, io.SeekCurrent)
-	fmt.Printf("After pointers: %d\n", curr)
-
-	for _, pointer := range blockPointers {
-		if pointer == 0 {
-			continue
-		}
-		file.Seek(startPos+int64(pointer)+1, io.SeekStart)
-
-		n := make([]byte, 3)
-		if err := binary.Read(file, binary.BigEndian, &n); err != nil {
-			panic(err.Error())
-		}
-
-		file.Seek(-4, io.SeekCurrent)
-
-		name := string(n)
-		curr, _ := file.Seek(0, io.SeekCurrent)
-		fmt.Printf("Before %s %d\n", name, curr)
-		switch name {
-		case "VOL":
-			binary.Read(file, binary.BigEndian, &message31.VolumeData)
-			fmt.Println(message31.VolumeData.LRTUP)
-		case "ELV":
-			binary.Read(file, binary.BigEndian, &message31.ElevationData)
-			fmt.Println(message31.ElevationData.LRTUP)
-
-		case "RAD":
-			binary.Read(file, binary.BigEndian, &message31.RadialData)
-			// fmt.Println(string(message31.RadialData.:]))
-
-		case "REF":
-			fallthrough
-		case "VEL":
-			fallthrough
-		case "SW ":
-
-			m := GenericMoment{}
-			binary.Read(file, binary.BigEndian, &m)
-
-			ldm := (m.NumberGates * uint16(m.DataWordSize)) / 8
-			fmt.Println(m.DataWordSize)
-
-			fmt.Println(m.NumberGates)
-			data := make([]byte, ldm)
-			binary.Read(file, binary.BigEndian, data)
-
-			converted := []float32{}
-			if m.Scale != 0 {
-				for _, n := range data {
-					converted = append(converted, (float32(n)-float32(m.Offset))/float32(m.Scale))
-				}
-			}
-
-			d := Moment{
-				GenericMoment: m,
-				Data:          converted,
-			}
-
-			message31.MomentData[name] = d
-		}
-		curr, _ = file.Seek(0, io.SeekCurrent)
-		fmt.Printf("After %s %d\n", name, curr)
+	header, momentData, err := level2.ParseRadial(file, blocks, momentNames)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Range %d\n", message31.RadialData.LRTUP)
+	message31.Header = *header
+	message31.MomentData = momentData
 
-	return message31
+	return &message31, nil
 }
@@ -1,7 +1,7 @@
 package tdwr
 
 import (
-	"encoding/binary"
+	"context"
 	"fmt"
 	"io"
 
@@ -9,7 +9,6 @@ import (
 )
 
 type ElevationMessages struct {
-	M5  []*Message5
 	M31 []*Message31
 }
 
@@ -17,98 +16,73 @@ type TDWR struct {
 	IsArchive      bool
 	ICAO           string
 	VolumeHeader   level2.VolumeHeader
-	VCP            Message5
+	VCP            *level2.Message5
 	ElevationScans map[int]*ElevationMessages
 }
 
-func ParseTDWR(file io.ReadSeeker) *TDWR {
-	// Start at the beginning of the file
-	file.Seek(0, io.SeekStart)
+func ParseTDWR(file io.ReadSeeker) (*TDWR, error) {
+	return ParseTDWRContext(context.Background(), file, level2.DefaultLimits)
+}
 
-	// Parse the Volume Header
-	header := level2.GetVolumeHeader(file)
+/*
+ParseTDWRContext parses the file, giving up once ctx is cancelled or a record breaks the limits.
+Chunks without a volume header are accepted as they can't be told apart from NEXRAD chunks, the
+ICAO then comes from the radials
+*/
+func ParseTDWRContext(ctx context.Context, file io.ReadSeeker, limits level2.Limits) (*TDWR, error) {
+	messages, err := level2.NewMessageReader(ctx, file, limits)
+	if err != nil {
+		return nil, err
+	}
+	if messages.Format.HasVolumeHeader && messages.Format.Parser != level2.ParserTDWR {
+		return nil, level2.NewError(level2.ErrUnknownFormat, 0, fmt.Errorf("%s is not a TDWR archive", messages.Format.Tape))
+	}
 
 	tdwr := TDWR{
-		VolumeHeader:   *header,
-		IsArchive:      string(header.Tape[:]) == "AR2V0008.",
+		IsArchive:      messages.Format.HasVolumeHeader,
+		VolumeHeader:   messages.VolumeHeader,
 		ElevationScans: make(map[int]*ElevationMessages),
 	}
-
-	// If the file is an archive file then the ICAO is provided for us already
 	if tdwr.IsArchive {
 		tdwr.ICAO = string(tdwr.VolumeHeader.ICAO[:])
-	} else {
-		file.Seek(0, io.SeekStart)
 	}
 
-	fmt.Println(string(header.ICAO[:]))
-
-	i := 0
-
 	for {
-
-		// Create the LDM Record
-		ldmRecord := level2.LDM{}
-		if err := binary.Read(file, binary.BigEndian, &ldmRecord.Size); err != nil {
+		header, body, err := messages.Next()
+		if err != nil {
 			if err != io.EOF {
-				panic(err)
+				return nil, err
 			}
 			break
 		}
 
-		if ldmRecord.Size < 0 {
-			ldmRecord.Size = -ldmRecord.Size
+		switch header.MessageType {
+		case 5:
+			tdwr.VCP, err = level2.ParseMessage5(body)
+		case 31:
+			var m31 *Message31
+			if m31, err = ParseMessage31(body); err == nil {
+				tdwr.addRadial(m31)
+			}
 		}
-
-		// Decompress the LDM Record
-		if level2.IsCompressed(file) {
-			ldmRecord.Data = level2.Decompress(file, int(ldmRecord.Size))
-		} else {
-			ldmRecord.Data = file
+		if err != nil {
+			return nil, messages.MessageError(header.MessageType, err)
 		}
+	}
 
-		curr, _ := ldmRecord.Data.Seek(0, io.SeekStart)
-		fmt.Printf("\nStarting position: %d\n", curr)
-
-		for {
-			curr, _ := ldmRecord.Data.Seek(level2.CTMHeaderSize, io.SeekCurrent)
-			fmt.Printf("Current position: %d\n", curr)
-
-			messageHeader := level2.MessageHeader{}
-			if err := binary.Read(ldmRecord.Data, binary.BigEndian, &messageHeader); err != nil {
-				if err != io.EOF {
-					panic(err)
-				}
-				break
-			}
-
-			curr, _ = ldmRecord.Data.Seek(0, io.SeekCurrent)
-			fmt.Printf("After header: %d\n", curr)
+	return &tdwr, nil
+}
 
-			fmt.Printf("Message: %d\n", messageHeader.MessageType)
+func (tdwr *TDWR) addRadial(m31 *Message31) {
+	if tdwr.ICAO == "" {
+		tdwr.ICAO = string(m31.Header.ICAO[:])
+	}
 
-			switch messageHeader.MessageType {
-			case 5:
-				tdwr.VCP = ParseMessage5(ldmRecord.Data)
-				ldmRecord.Data.Seek(int64(level2.DefaultMessageSize-(messageHeader.Size*2)-level2.CTMHeaderSize), io.SeekCurrent)
-			case 31:
-				fmt.Printf("Message size: %d\n", messageHeader.Size*2)
-				ParseMessage31(ldmRecord.Data)
-				curr, _ = ldmRecord.Data.Seek(0, io.SeekCurrent)
-				fmt.Printf("After message: %d\n", curr)
-				// break
-			default:
-				ldmRecord.Data.Seek(int64(level2.MessageBodySize), io.SeekCurrent)
-			}
+	elevation := int(m31.Header.ElevationNumber)
+	if tdwr.ElevationScans[elevation] == nil {
+		tdwr.ElevationScans[elevation] = &ElevationMessages{
+			M31: []*Message31{},
 		}
-
-		i++
-
-		// if i == 2 {
-		// 	break
-		// }
-
 	}
-
-	return &tdwr
+	tdwr.ElevationScans[elevation].M31 = append(tdwr.ElevationScans[elevation].M31, m31)
 }
@@ -0,0 +1,78 @@
+package tdwr
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestParseTDWR(t *testing.T) {
+	for _, name := range []string{"TATL20240421_001129_V08", "TTUL20240418_132617_V08"} {
+		file, err := os.Open("../../test/archive/" + name)
+		if os.IsNotExist(err) {
+			t.Skipf("%s is not checked out", name)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		radar, err := ParseTDWR(file)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if radar.ICAO != name[:4] || radar.VCP == nil || radar.VCP.Header.PatternNumber != 90 {
+			t.Errorf("%s: ICAO %q, VCP %+v", name, radar.ICAO, radar.VCP)
+		}
+		if len(radar.ElevationScans) != 16 {
+			t.Fatalf("%s: %d elevations, want 16", name, len(radar.ElevationScans))
+		}
+
+		for elevation := 1; elevation <= 16; elevation++ {
+			scan := radar.ElevationScans[elevation]
+			if scan == nil || len(scan.M31) != 360 {
+				t.Errorf("%s: elevation %d has the wrong number of radials", name, elevation)
+				continue
+			}
+			for _, m31 := range scan.M31 {
+				if _, ok := m31.MomentData["VEL"]; !ok {
+					continue
+				}
+				// The PRFs of VCP 90 give Nyquist velocities between 10 and 30 m/s
+				if v := m31.RadialData.NyquistVelocity(); v < 10 || v > 30 {
+					t.Errorf("%s: elevation %d Nyquist velocity %v", name, elevation, v)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestVolume(t *testing.T) {
+	file, err := os.Open("../../test/archive/TATL20240421_001129_V08")
+	if os.IsNotExist(err) {
+		t.Skip("TATL20240421_001129_V08 is not checked out")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	radar, err := ParseTDWR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume := radar.Volume()
+
+	site := volume.Site
+	if site == nil || math.Abs(site.Lat-33.647) > 0.001 || math.Abs(site.Lon+84.262) > 0.001 || site.Height != 327 {
+		t.Fatalf("site %+v, want 33.647 -84.262 at 327 m", site)
+	}
+	if volume.ICAO != "TATL" || volume.VCP != 90 || len(volume.Sweeps) != 16 {
+		t.Errorf("ICAO %s, VCP %d, %d sweeps", volume.ICAO, volume.VCP, len(volume.Sweeps))
+	}
+	if ray := volume.Sweeps[4].Rays[0]; ray.NyquistVelocity < 10 || ray.Moments["VEL"] == nil {
+		t.Errorf("sweep 5 ray has a Nyquist velocity of %v", ray.NyquistVelocity)
+	}
+}
@@ -0,0 +1,59 @@
+package tdwr
+
+import (
+	"sort"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Ray converts the radial to the format-agnostic model
+func (m31 *Message31) Ray() *level2.Ray {
+	return m31.Header.Ray(m31.RadialData.NyquistVelocity(), float32(m31.RadialData.Range)/10.0, m31.MomentData)
+}
+
+/*
+Volume converts the parsed file to the format-agnostic model. The site comes from the volume block of
+the radials. TDWR sends the height of the feedhorn above sea level as both the site and feedhorn
+height, so only one of them is used
+*/
+func (tdwr *TDWR) Volume() *level2.Volume {
+	volume := level2.Volume{
+		ICAO:   tdwr.ICAO,
+		Sweeps: []*level2.Sweep{},
+	}
+
+	if tdwr.IsArchive {
+		volume.Time = tdwr.VolumeHeader.Date()
+	}
+
+	if tdwr.VCP != nil {
+		volume.VCP = int(tdwr.VCP.Header.PatternNumber)
+	}
+
+	elevations := make([]int, 0, len(tdwr.ElevationScans))
+	for k := range tdwr.ElevationScans {
+		elevations = append(elevations, k)
+	}
+	sort.Ints(elevations)
+
+	for _, elevation := range elevations {
+		for _, m31 := range tdwr.ElevationScans[elevation].M31 {
+			if volume.VCP == 0 {
+				volume.VCP = int(m31.VolumeData.VCPNumber)
+			}
+			if volume.Site == nil && (m31.VolumeData.Lat != 0 || m31.VolumeData.Long != 0) {
+				volume.Site = &level2.Site{
+					Lat:    float64(m31.VolumeData.Lat),
+					Lon:    float64(m31.VolumeData.Long),
+					Height: float64(m31.VolumeData.Height),
+				}
+			}
+
+			volume.AddRadial(&m31.Header, m31.Ray())
+		}
+	}
+
+	volume.TagSupplemental()
+
+	return &volume
+}
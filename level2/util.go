@@ -3,58 +3,113 @@ package level2
 import (
 	"bytes"
 	"compress/bzip2"
-	"encoding/binary"
 	"io"
 	"time"
+
+	dsbzip2 "github.com/dsnet/compress/bzip2"
 )
 
-func IsCompressed(file io.ReadSeeker) bool {
+func IsCompressed(file io.ReadSeeker) (bool, error) {
+	offset, _ := file.Seek(0, io.SeekCurrent)
+
 	b := make([]byte, 2)
-	_, err := file.Read(b)
-	if err != nil {
-		panic(err)
+	if _, err := io.ReadFull(file, b); err != nil {
+		return false, NewError(ErrTruncatedRecord, offset, err)
 	}
 	file.Seek(-2, io.SeekCurrent)
-	return string(b) == "BZ"
+	return string(b) == "BZ", nil
+}
+
+// Limits bounds the memory that reading a single file may use. A value of zero means no limit
+type Limits struct {
+	MaxRecordSize       int64 // Largest compressed LDM record
+	MaxDecompressedSize int64 // Largest LDM record once decompressed
+	MaxVolumeSize       int64 // Total decompressed size of all records in the file
+}
+
+var DefaultLimits = Limits{
+	MaxRecordSize:       16 << 20,
+	MaxDecompressedSize: 64 << 20,
+	MaxVolumeSize:       1 << 30,
 }
 
-func Decompress(file io.ReadSeeker, size int) *bytes.Reader {
+/*
+Decompresses the LDM record at the current position, returning ErrSizeLimit if the compressed record
+is larger than maxSize or would decompress to more than maxDecompressed bytes
+*/
+func DecompressWithLimit(file io.ReadSeeker, size int, maxSize int64, maxDecompressed int64) (*bytes.Reader, error) {
+	offset, _ := file.Seek(0, io.SeekCurrent)
+
+	if size < 0 || (maxSize > 0 && int64(size) > maxSize) {
+		return nil, NewError(ErrSizeLimit, offset, nil)
+	}
+
 	compressedData := make([]byte, size)
-	binary.Read(file, binary.BigEndian, &compressedData)
-	bz2Reader := bzip2.NewReader(bytes.NewReader(compressedData))
+	if _, err := io.ReadFull(file, compressedData); err != nil {
+		return nil, NewError(ErrTruncatedRecord, offset, err)
+	}
+
+	var bz2Reader io.Reader = bzip2.NewReader(bytes.NewReader(compressedData))
+	if maxDecompressed > 0 {
+		bz2Reader = io.LimitReader(bz2Reader, maxDecompressed+1)
+	}
+
 	extractedData := bytes.NewBuffer([]byte{})
-	io.Copy(extractedData, bz2Reader)
-	return bytes.NewReader(extractedData.Bytes())
+	n, err := io.Copy(extractedData, bz2Reader)
+	if err != nil {
+		return nil, NewError(ErrBadCompression, offset, err)
+	}
+	if maxDecompressed > 0 && n > maxDecompressed {
+		return nil, NewError(ErrSizeLimit, offset, nil)
+	}
+
+	return bytes.NewReader(extractedData.Bytes()), nil
+}
+
+func Decompress(file io.ReadSeeker, size int) (*bytes.Reader, error) {
+	return DecompressWithLimit(file, size, 0, 0)
+}
+
+// Compress bzip2 compresses a record to be written as an LDM record
+func Compress(data []byte) ([]byte, error) {
+	compressed := bytes.NewBuffer([]byte{})
+
+	writer, err := dsbzip2.NewWriter(compressed, &dsbzip2.WriterConfig{Level: dsbzip2.BestCompression})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
 }
 
+// JulianDateToTime converts a modified Julian date, where day 1 is 1 January 1970, and milliseconds past
+// midnight to a time
 func JulianDateToTime(d uint32, t uint32) time.Time {
 	return time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC).
-		Add(time.Duration(d) * time.Hour * 24).
+		Add(time.Duration(int64(d)-1) * time.Hour * 24).
 		Add(time.Duration(t) * time.Millisecond)
 }
 
 func IsNexradArchive(file io.ReadSeeker) (bool, error) {
-	file.Seek(0, io.SeekStart)
-
-	header, err := GetVolumeHeader(file)
+	format, err := DetectFormat(file)
 	if err != nil {
 		return false, err
 	}
 
-	file.Seek(0, io.SeekStart)
-
-	return string(header.Tape[:]) == "AR2V0006.", nil
+	return format.HasVolumeHeader && format.Parser == ParserNexrad, nil
 }
 
 func IsTDWRArchive(file io.ReadSeeker) (bool, error) {
-	file.Seek(0, io.SeekStart)
-
-	header, err := GetVolumeHeader(file)
+	format, err := DetectFormat(file)
 	if err != nil {
 		return false, err
 	}
 
-	file.Seek(0, io.SeekStart)
-
-	return string(header.Tape[:]) == "AR2V0008.", nil
+	return format.Parser == ParserTDWR, nil
 }
@@ -0,0 +1,178 @@
+package level2
+
+// Coded fields of Message 5 (Volume Coverage Pattern), shared by the NEXRAD and TDWR decoders
+
+// Angle is an angle coded in 360/65536 degree steps
+type Angle uint16
+
+// Degrees returns the angle between 0 and 360 degrees
+func (a Angle) Degrees() float32 {
+	return float32(a) * 360.0 / 65536.0
+}
+
+// Elevation returns the angle between -180 and 180 degrees so that negative tilts come out negative
+func (a Angle) Elevation() float32 {
+	return float32(int16(a)) * 360.0 / 65536.0
+}
+
+// AngleRate is an angular velocity coded in 90/65536 degree per second steps
+type AngleRate uint16
+
+func (r AngleRate) DegreesPerSecond() float32 {
+	return float32(r) * 90.0 / 65536.0
+}
+
+// SNRThreshold is a signal to noise threshold coded in 1/8 dB steps
+type SNRThreshold int16
+
+func (t SNRThreshold) DB() float32 {
+	return float32(t) / 8.0
+}
+
+type Waveform uint8
+
+const (
+	WaveformCS   Waveform = 1 // Contiguous Surveillance
+	WaveformCDW  Waveform = 2 // Contiguous Doppler with ambiguity resolution
+	WaveformCDWO Waveform = 3 // Contiguous Doppler without ambiguity resolution
+	WaveformB    Waveform = 4 // Batch
+	WaveformSPP  Waveform = 5 // Staggered Pulse Pair
+)
+
+var waveforms = map[Waveform]string{
+	WaveformCS:   "CS",
+	WaveformCDW:  "CDW",
+	WaveformCDWO: "CDWO",
+	WaveformB:    "B",
+	WaveformSPP:  "SPP",
+}
+
+func (w Waveform) String() string {
+	if s, ok := waveforms[w]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+type ChannelConfig uint8
+
+const (
+	ConstantPhase ChannelConfig = 0
+	RandomPhase   ChannelConfig = 1
+	SZ2Phase      ChannelConfig = 2
+)
+
+var channelConfigs = map[ChannelConfig]string{
+	ConstantPhase: "Constant Phase",
+	RandomPhase:   "Random Phase",
+	SZ2Phase:      "SZ2 Phase",
+}
+
+func (c ChannelConfig) String() string {
+	if s, ok := channelConfigs[c]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+// SuperResControl flags which super resolution products an elevation cut produces
+type SuperResControl uint8
+
+func (s SuperResControl) HalfDegreeAzimuth() bool {
+	return s&1 != 0
+}
+
+func (s SuperResControl) QuarterKmReflectivity() bool {
+	return s&2 != 0
+}
+
+func (s SuperResControl) Doppler300km() bool {
+	return s&4 != 0
+}
+
+func (s SuperResControl) DualPol300km() bool {
+	return s&8 != 0
+}
+
+// VCPSequencing describes how the VCP is being run
+type VCPSequencing uint16
+
+func (s VCPSequencing) Elevations() int {
+	return int(s & 0x1F)
+}
+
+func (s VCPSequencing) MaxSAILSCuts() int {
+	return int(s>>5) & 0x3
+}
+
+func (s VCPSequencing) SequenceActive() bool {
+	return s&(1<<13) != 0
+}
+
+func (s VCPSequencing) Truncated() bool {
+	return s&(1<<14) != 0
+}
+
+// VCPSupplementalData flags the extra cuts added to the VCP
+type VCPSupplementalData uint16
+
+// SAILS reports whether supplemental low level (SAILS) cuts are inserted
+func (s VCPSupplementalData) SAILS() bool {
+	return s&1 != 0
+}
+
+func (s VCPSupplementalData) SAILSCuts() int {
+	return int(s>>1) & 0x7
+}
+
+// MRLE reports whether Mid-volume Rescan of Low Level Elevations is used
+func (s VCPSupplementalData) MRLE() bool {
+	return s&(1<<4) != 0
+}
+
+func (s VCPSupplementalData) MRLECuts() int {
+	return int(s>>5) & 0x7
+}
+
+// MPDA reports whether the VCP uses Multiple PRF Dealiasing
+func (s VCPSupplementalData) MPDA() bool {
+	return s&(1<<11) != 0
+}
+
+// BaseTilt reports whether the VCP is a base tilt VCP
+func (s VCPSupplementalData) BaseTilt() bool {
+	return s&(1<<12) != 0
+}
+
+func (s VCPSupplementalData) BaseTilts() int {
+	return int(s>>13) & 0x7
+}
+
+// CutSupplementalData flags what an elevation cut is used for
+type CutSupplementalData uint16
+
+func (s CutSupplementalData) SAILSCut() bool {
+	return s&1 != 0
+}
+
+// SAILSSequence is the number of the SAILS cut in the volume, starting at 1
+func (s CutSupplementalData) SAILSSequence() int {
+	return int(s>>1) & 0x7
+}
+
+func (s CutSupplementalData) MRLECut() bool {
+	return s&(1<<4) != 0
+}
+
+// MRLESequence is the number of the MRLE cut in the volume, starting at 1
+func (s CutSupplementalData) MRLESequence() int {
+	return int(s>>5) & 0x7
+}
+
+func (s CutSupplementalData) MPDACut() bool {
+	return s&(1<<9) != 0
+}
+
+func (s CutSupplementalData) BaseTiltCut() bool {
+	return s&(1<<10) != 0
+}
@@ -0,0 +1,135 @@
+package level2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAngle(t *testing.T) {
+	tests := []struct {
+		word      Angle
+		degrees   float32
+		elevation float32
+	}{
+		// Cuts of the KHDX VCP 215
+		{0x0058, 0.483, 0.483},
+		{0x0de0, 19.512, 19.512},
+		{0x4000, 90, 90},
+		{0x8000, 180, -180},
+		// A negative tilt of -0.2 degrees
+		{0xffdc, 359.802, -0.198},
+	}
+
+	for _, test := range tests {
+		if d := test.word.Degrees(); math.Abs(float64(d-test.degrees)) > 0.001 {
+			t.Errorf("%#04x is %v degrees, want %v", uint16(test.word), d, test.degrees)
+		}
+		if e := test.word.Elevation(); math.Abs(float64(e-test.elevation)) > 0.001 {
+			t.Errorf("%#04x is an elevation of %v, want %v", uint16(test.word), e, test.elevation)
+		}
+	}
+
+	// The first cut of the KHDX VCP 215
+	if r := AngleRate(0x20a0).DegreesPerSecond(); math.Abs(float64(r)-11.470) > 0.001 {
+		t.Errorf("azimuth rate %v, want 11.470", r)
+	}
+	if db := SNRThreshold(-12).DB(); db != -1.5 {
+		t.Errorf("threshold %v dB, want -1.5", db)
+	}
+}
+
+func TestSuperResControl(t *testing.T) {
+	tests := []struct {
+		word                                       SuperResControl
+		halfDegree, quarterKm, doppler, dualPol300 bool
+	}{
+		// The surveillance, Doppler, batch and high cuts of the KHDX VCP 215
+		{0x0b, true, true, false, true},
+		{0x07, true, true, true, false},
+		{0x0e, false, true, true, true},
+		{0x0a, false, true, false, true},
+		{0x00, false, false, false, false},
+	}
+
+	for _, test := range tests {
+		s := test.word
+		if s.HalfDegreeAzimuth() != test.halfDegree || s.QuarterKmReflectivity() != test.quarterKm || s.Doppler300km() != test.doppler || s.DualPol300km() != test.dualPol300 {
+			t.Errorf("%#02x = %v %v %v %v", uint8(s), s.HalfDegreeAzimuth(), s.QuarterKmReflectivity(), s.Doppler300km(), s.DualPol300km())
+		}
+	}
+}
+
+func TestVCPSequencing(t *testing.T) {
+	tests := []struct {
+		word              VCPSequencing
+		elevations, sails int
+		active, truncated bool
+	}{
+		{0x0000, 0, 0, false, false},
+		{0x0010, 16, 0, false, false},
+		{0x6050, 16, 2, true, true},
+		{0x206e, 14, 3, true, false},
+	}
+
+	for _, test := range tests {
+		s := test.word
+		if s.Elevations() != test.elevations || s.MaxSAILSCuts() != test.sails || s.SequenceActive() != test.active || s.Truncated() != test.truncated {
+			t.Errorf("%#04x = %d %d %v %v", uint16(s), s.Elevations(), s.MaxSAILSCuts(), s.SequenceActive(), s.Truncated())
+		}
+	}
+}
+
+func TestVCPSupplementalData(t *testing.T) {
+	tests := []struct {
+		name           string
+		word           VCPSupplementalData
+		sails          bool
+		sailsCuts      int
+		mrle           bool
+		mrleCuts       int
+		mpda, baseTilt bool
+		baseTilts      int
+	}{
+		{"none", 0x0000, false, 0, false, 0, false, false, 0},
+		{"one SAILS cut", 0x0003, true, 1, false, 0, false, false, 0},
+		{"three SAILS cuts", 0x0007, true, 3, false, 0, false, false, 0},
+		{"two MRLE cuts", 0x0050, false, 0, true, 2, false, false, 0},
+		{"MPDA", 0x0800, false, 0, false, 0, true, false, 0},
+		{"three base tilts", 0x7000, false, 0, false, 0, false, true, 3},
+		{"SAILS and MRLE", 0x0095, true, 2, true, 4, false, false, 0},
+	}
+
+	for _, test := range tests {
+		s := test.word
+		if s.SAILS() != test.sails || s.SAILSCuts() != test.sailsCuts || s.MRLE() != test.mrle || s.MRLECuts() != test.mrleCuts ||
+			s.MPDA() != test.mpda || s.BaseTilt() != test.baseTilt || s.BaseTilts() != test.baseTilts {
+			t.Errorf("%s: %#04x = %v %d %v %d %v %v %d", test.name, uint16(s), s.SAILS(), s.SAILSCuts(), s.MRLE(), s.MRLECuts(), s.MPDA(), s.BaseTilt(), s.BaseTilts())
+		}
+	}
+}
+
+func TestCutSupplementalData(t *testing.T) {
+	tests := []struct {
+		name          string
+		word          CutSupplementalData
+		sails         bool
+		sailsSequence int
+		mrle          bool
+		mrleSequence  int
+		mpda, base    bool
+	}{
+		{"plain cut", 0x0000, false, 0, false, 0, false, false},
+		{"second SAILS cut", 0x0005, true, 2, false, 0, false, false},
+		{"first MRLE cut", 0x0030, false, 0, true, 1, false, false},
+		{"MPDA cut", 0x0200, false, 0, false, 0, true, false},
+		{"base tilt", 0x0400, false, 0, false, 0, false, true},
+	}
+
+	for _, test := range tests {
+		s := test.word
+		if s.SAILSCut() != test.sails || s.SAILSSequence() != test.sailsSequence || s.MRLECut() != test.mrle || s.MRLESequence() != test.mrleSequence ||
+			s.MPDACut() != test.mpda || s.BaseTiltCut() != test.base {
+			t.Errorf("%s: %#04x = %v %d %v %d %v %v", test.name, uint16(s), s.SAILSCut(), s.SAILSSequence(), s.MRLECut(), s.MRLESequence(), s.MPDACut(), s.BaseTiltCut())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package level2
+
+import (
+	"sort"
+	"time"
+)
+
+/*
+Volume is a radar volume in physical units. It does not depend on the radar type it was decoded from
+so that product code can work on NEXRAD and TDWR data alike
+*/
+type Volume struct {
+	ICAO   string
+	Time   time.Time // Time of the volume header, or of the first ray if there is no header
+	VCP    int       // 0 if the VCP was not sent
+	Site   *Site     // nil if the location of the radar is not known
+	Sweeps []*Sweep  // Ordered by elevation number
+}
+
+type Site struct {
+	Name   string  // Site name from the RDA adaptation data, empty if it was not sent
+	Lat    float64 // degrees
+	Lon    float64 // degrees
+	Height float64 // m above sea level
+}
+
+type Sweep struct {
+	Number            int
+	ElevationAngle    float32 // Mean elevation angle of the rays, degrees
+	AzimuthResolution float32 // degrees
+	Supplemental      bool    // A SAILS or MRLE rescan of a lower tilt part way through the volume
+	Base              int     // Elevation number of the sweep a supplemental sweep repeats, 0 otherwise
+	Rays              []*Ray  // In the order the radar sent them, see Sorted for azimuth order
+}
+
+// RadialStatus marks where a ray falls in the elevation and volume
+type RadialStatus uint8
+
+const (
+	StartOfElevation RadialStatus = iota
+	IntermediateRadial
+	EndOfElevation
+	StartOfVolume
+	EndOfVolume
+	StartOfLastElevation
+)
+
+type Ray struct {
+	Time             time.Time
+	AzimuthNumber    int
+	Azimuth          float32 // degrees
+	Elevation        float32 // degrees
+	Status           RadialStatus
+	NyquistVelocity  float32 // m/s
+	UnambiguousRange float32 // km
+	Moments          map[string]*Moment
+}
+
+type Moment struct {
+	Name         string
+	FirstGate    float32 // Range to the first gate, km
+	GateInterval float32 // km
+	Gates
+}
+
+// Sweep returns the sweep with the given elevation number, or nil if there is none
+func (v *Volume) Sweep(number int) *Sweep {
+	for _, s := range v.Sweeps {
+		if s.Number == number {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddRay adds the ray to the sweep with the given elevation number, creating the sweep if needed
+func (v *Volume) AddRay(number int, ray *Ray) *Sweep {
+	sweep := v.Sweep(number)
+	if sweep == nil {
+		sweep = &Sweep{
+			Number: number,
+			Rays:   []*Ray{},
+		}
+		v.Sweeps = append(v.Sweeps, sweep)
+		sort.Slice(v.Sweeps, func(i, j int) bool {
+			return v.Sweeps[i].Number < v.Sweeps[j].Number
+		})
+	}
+
+	sweep.Rays = append(sweep.Rays, ray)
+	sweep.ElevationAngle += (ray.Elevation - sweep.ElevationAngle) / float32(len(sweep.Rays))
+
+	if v.Time.IsZero() {
+		v.Time = ray.Time
+	}
+
+	return sweep
+}
+
+// AddRadial adds the ray of a Message 31 radial to the sweep of its elevation number
+func (v *Volume) AddRadial(header *Message31Header, ray *Ray) *Sweep {
+	sweep := v.AddRay(int(header.ElevationNumber), ray)
+	if sweep.AzimuthResolution == 0 {
+		sweep.AzimuthResolution = float32(header.AzimuthResolution) / 2.0
+	}
+	return sweep
+}
+
+// EndOfElevation reports whether the ray is the last of its elevation
+func (r *Ray) EndOfElevation() bool {
+	return r.Status == EndOfElevation || r.Status == EndOfVolume
+}
+
+// EndOfVolume reports whether the ray is the last of the volume
+func (r *Ray) EndOfVolume() bool {
+	return r.Status == EndOfVolume
+}
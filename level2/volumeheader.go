@@ -19,12 +19,12 @@ func (v *VolumeHeader) Date() time.Time {
 }
 
 func GetVolumeHeader(file io.ReadSeeker) (*VolumeHeader, error) {
-	file.Seek(0, io.SeekCurrent)
+	offset, _ := file.Seek(0, io.SeekCurrent)
 	volumeHeader := VolumeHeader{}
 
 	err := binary.Read(file, binary.BigEndian, &volumeHeader)
 	if err != nil {
-		return nil, err
+		return nil, NewError(ErrTruncatedRecord, offset, err)
 	}
 
 	return &volumeHeader, nil
@@ -0,0 +1,159 @@
+package level3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Symbology is the Product Symbology Block, which holds the data of the product as layers of packets
+type Symbology struct {
+	Layers [][]Packet
+}
+
+// Graphic is the Graphic Alphanumeric Block, pages of text and graphic packets shown beside the product
+type Graphic struct {
+	Pages [][]Packet
+}
+
+// Tabular is the Tabular Alphanumeric Block, pages of 80 column text such as the storm tables of NST
+type Tabular struct {
+	Header      MessageHeader
+	Description ProductDescription
+	Pages       [][]string
+}
+
+// The header of the symbology, graphic and tabular blocks
+type blockHeader struct {
+	Divider int16
+	ID      int16
+	Length  uint32 // Bytes including this header
+}
+
+// Checks the block header and returns the data of the block after it
+func readBlock(data []byte, offset int64, id int16) ([]byte, error) {
+	header := blockHeader{}
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &header); err != nil {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, offset, err)
+	}
+	if header.Divider != -1 || header.ID != id {
+		return nil, level2.NewError(level2.ErrCorruptMessage, offset, fmt.Errorf("expected block %d, found %d", id, header.ID))
+	}
+	if header.Length < 8 || int64(header.Length) > int64(len(data)) {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, offset, fmt.Errorf("block %d length %d", id, header.Length))
+	}
+	return data[8:header.Length], nil
+}
+
+func (p *Product) parseSymbology(data []byte, offset int64) error {
+	data, err := readBlock(data, offset, 1)
+	if err != nil {
+		return err
+	}
+	offset += 8
+
+	r := newReader(data, offset)
+	layers := int(r.uint16())
+	if r.err != nil {
+		return r.err
+	}
+
+	symbology := Symbology{}
+	for i := 0; i < layers; i++ {
+		if r.int16() != -1 {
+			return r.fail(fmt.Errorf("no divider before layer %d", i+1))
+		}
+		layer := r.bytes(int(r.uint32()))
+		if r.err != nil {
+			return r.err
+		}
+
+		packets, err := parsePackets(layer, r.offset-int64(len(layer)), false)
+		if err != nil {
+			return err
+		}
+		symbology.Layers = append(symbology.Layers, packets)
+	}
+
+	p.Symbology = &symbology
+	return nil
+}
+
+func (p *Product) parseGraphic(data []byte, offset int64) error {
+	data, err := readBlock(data, offset, 2)
+	if err != nil {
+		return err
+	}
+	offset += 8
+
+	r := newReader(data, offset)
+	pages := int(r.uint16())
+
+	graphic := Graphic{}
+	for i := 0; i < pages; i++ {
+		r.uint16() // Page number
+		page := r.bytes(int(r.uint16()))
+		if r.err != nil {
+			return r.err
+		}
+
+		packets, err := parsePackets(page, r.offset-int64(len(page)), false)
+		if err != nil {
+			return err
+		}
+		graphic.Pages = append(graphic.Pages, packets)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	p.Graphic = &graphic
+	return nil
+}
+
+func (p *Product) parseTabular(data []byte, offset int64) error {
+	data, err := readBlock(data, offset, 3)
+	if err != nil {
+		return err
+	}
+	offset += 8
+
+	tabular := Tabular{}
+
+	// The block repeats the message header and PDB before the pages
+	b := bytes.NewReader(data)
+	if err := binary.Read(b, binary.BigEndian, &tabular.Header); err != nil {
+		return level2.NewError(level2.ErrTruncatedRecord, offset, err)
+	}
+	if err := binary.Read(b, binary.BigEndian, &tabular.Description); err != nil {
+		return level2.NewError(level2.ErrTruncatedRecord, offset, err)
+	}
+
+	r := newReader(data[MessageHeaderSize+ProductDescriptionSize:], offset+MessageHeaderSize+ProductDescriptionSize)
+	if r.int16() != -1 {
+		return r.fail(fmt.Errorf("no divider before the pages"))
+	}
+	pages := int(r.uint16())
+
+	for i := 0; i < pages && r.err == nil; i++ {
+		page := []string{}
+		for {
+			// Each page ends with -1 in place of a line length
+			n := r.int16()
+			if r.err != nil || n == -1 {
+				break
+			}
+			page = append(page, strings.TrimRight(string(r.bytes(int(n))), " \x00"))
+		}
+		tabular.Pages = append(tabular.Pages, page)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	p.Tabular = &tabular
+	return nil
+}
@@ -0,0 +1,223 @@
+package level3
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+/*
+GenericPacket is packet 28, which newer products such as DPR use to send their data serialised with
+XDR. Times are seconds since 1 January 1970
+*/
+type GenericPacket struct {
+	Name            string
+	Description     string
+	Code            int32
+	Type            int32
+	GenerationTime  uint32
+	RadarName       string
+	Lat             float32 // degrees
+	Lon             float32 // degrees
+	Height          float32 // m
+	VolumeTime      uint32
+	ElevationTime   uint32
+	ElevationAngle  float32 // degrees
+	VolumeNumber    int32
+	OperationalMode int32
+	VCP             int32
+	ElevationNumber int32
+	Parameters      []Parameter
+	Components      []Component
+}
+
+type Parameter struct {
+	ID         string
+	Attributes string // e.g. "scale=0.001;offset=0"
+}
+
+// Component is one of the parts of a generic product
+type Component interface {
+	ComponentType() int32
+}
+
+// Generic component types
+const (
+	ComponentRadial = 1
+	ComponentArea   = 2
+	ComponentGrid   = 3
+	ComponentText   = 4
+)
+
+type RadialComponent struct {
+	Description string
+	BinSize     float32 // m
+	FirstGate   float32 // m
+	Parameters  []Parameter
+	Radials     []GenericRadial
+}
+
+type GenericRadial struct {
+	Azimuth    float32 // degrees
+	Elevation  float32 // degrees
+	Width      float32 // degrees
+	Attributes string
+	Data       []int32 // One value per bin, scaled as the product parameters say
+}
+
+type TextComponent struct {
+	Parameters []Parameter
+	Text       string
+}
+
+func (p *GenericPacket) PacketCode() uint16     { return PacketGeneric }
+func (c *RadialComponent) ComponentType() int32 { return ComponentRadial }
+func (c *TextComponent) ComponentType() int32   { return ComponentText }
+
+func (p *GenericPacket) Generated() time.Time {
+	return time.Unix(int64(p.GenerationTime), 0).UTC()
+}
+
+func (p *GenericPacket) VolumeDate() time.Time {
+	return time.Unix(int64(p.VolumeTime), 0).UTC()
+}
+
+func readGenericPacket(r *reader) Packet {
+	r.int16() // Reserved
+	n := int(r.uint32())
+	data := r.bytes(n)
+	if r.err != nil {
+		return nil
+	}
+
+	x := newReader(data, r.offset-int64(n))
+	packet := GenericPacket{
+		Name:            x.string(),
+		Description:     x.string(),
+		Code:            x.int32(),
+		Type:            x.int32(),
+		GenerationTime:  x.uint32(),
+		RadarName:       x.string(),
+		Lat:             x.float32(),
+		Lon:             x.float32(),
+		Height:          x.float32(),
+		VolumeTime:      x.uint32(),
+		ElevationTime:   x.uint32(),
+		ElevationAngle:  x.float32(),
+		VolumeNumber:    x.int32(),
+		OperationalMode: x.int32(),
+		VCP:             x.int32(),
+		ElevationNumber: x.int32(),
+	}
+	compression := x.int32()
+	size := x.int32()
+
+	// The rest of the product may be bzip2 compressed, size being what it decompresses to
+	if compression != 0 && x.err == nil {
+		if size < 0 || int64(size) > level2.DefaultLimits.MaxDecompressedSize {
+			r.fail(fmt.Errorf("generic product of %d bytes", size))
+			return nil
+		}
+		offset := x.offset
+		rest := x.bytes(x.remaining())
+		decompressed, err := level2.DecompressWithLimit(bytes.NewReader(rest), len(rest), 0, int64(size))
+		if err != nil {
+			r.err = level2.RecordError(err, offset)
+			return nil
+		}
+		b := make([]byte, decompressed.Len())
+		decompressed.Read(b)
+		x = newReader(b, offset)
+	}
+
+	packet.Parameters = x.parameters()
+	x.list(func() {
+		switch t := x.int32(); t {
+		case ComponentRadial:
+			packet.Components = append(packet.Components, x.radialComponent())
+		case ComponentText:
+			packet.Components = append(packet.Components, &TextComponent{
+				Parameters: x.parameters(),
+				Text:       x.string(),
+			})
+		default:
+			x.fail(fmt.Errorf("generic component type %d is not supported", t))
+		}
+	})
+
+	if x.err != nil {
+		r.err = x.err
+		return nil
+	}
+	return &packet
+}
+
+// Reads an XDR string, a length then the bytes padded to a word
+func (r *reader) string() string {
+	n := int(r.uint32())
+	b := r.bytes(n + (4-n%4)%4)
+	if b == nil {
+		return ""
+	}
+	return string(b[:n])
+}
+
+/*
+Reads an XDR array of optional items, a count then each item behind a flag saying whether it is
+there. item is called for each item that is there
+*/
+func (r *reader) list(item func()) {
+	n := int(r.uint32())
+	// Each item takes at least its flag so a count larger than that is corrupt
+	if n < 0 || n > r.remaining()/4 {
+		r.fail(fmt.Errorf("list of %d items", n))
+		return
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		if r.uint32() != 0 {
+			item()
+		}
+	}
+}
+
+func (r *reader) parameters() []Parameter {
+	parameters := []Parameter{}
+	r.list(func() {
+		parameters = append(parameters, Parameter{ID: r.string(), Attributes: r.string()})
+	})
+	return parameters
+}
+
+func (r *reader) radialComponent() *RadialComponent {
+	component := RadialComponent{
+		Description: r.string(),
+		BinSize:     r.float32(),
+		FirstGate:   r.float32(),
+		Parameters:  r.parameters(),
+	}
+
+	r.list(func() {
+		radial := GenericRadial{
+			Azimuth:   r.float32(),
+			Elevation: r.float32(),
+			Width:     r.float32(),
+		}
+		r.int32() // Number of bins, given again by the data
+		radial.Attributes = r.string()
+
+		n := int(r.uint32())
+		if n < 0 || n > r.remaining()/4 {
+			r.fail(fmt.Errorf("radial of %d bins", n))
+			return
+		}
+		radial.Data = make([]int32, n)
+		for i := range radial.Data {
+			radial.Data[i] = r.int32()
+		}
+		component.Radials = append(component.Radials, radial)
+	})
+
+	return &component
+}
@@ -0,0 +1,9 @@
+module github.com/TheRangiCrew/NEXRAD-GO/level3
+
+replace github.com/TheRangiCrew/NEXRAD-GO/level2 => ../level2
+
+go 1.22.1
+
+require github.com/TheRangiCrew/NEXRAD-GO/level2 v0.0.0-20240419005628-d98dda7ac56e
+
+require github.com/dsnet/compress v0.0.1 // indirect
@@ -0,0 +1,92 @@
+package level3
+
+import (
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+const MessageHeaderSize = 18
+const ProductDescriptionSize = 102
+
+// The header every product message starts with
+type MessageHeader struct {
+	Code        int16
+	Date        uint16 // Modified Julian date, see level2.JulianDateToTime
+	Time        uint32 // Seconds after midnight
+	Length      uint32 // Bytes in the message including this header
+	Source      uint16
+	Destination uint16
+	Blocks      uint16 // Including this header
+}
+
+// Sent returns when the message was sent
+func (h *MessageHeader) Sent() time.Time {
+	return level2.JulianDateToTime(uint32(h.Date), h.Time*1000)
+}
+
+/*
+ProductDescription is the Product Description Block (PDB). The product dependent parameters P1 to P10
+and the thresholds differ between products, see Product.DataLevels for the thresholds of the common ones
+*/
+type ProductDescription struct {
+	Divider          int16
+	Latitude         int32 // 0.001 degrees
+	Longitude        int32 // 0.001 degrees
+	Height           int16 // ft above sea level
+	Code             int16
+	OperationalMode  uint16
+	VCP              uint16
+	SequenceNumber   int16
+	VolumeScanNumber uint16
+	VolumeScanDate   uint16
+	VolumeScanTime   uint32 // Seconds after midnight
+	GenerationDate   uint16
+	GenerationTime   uint32 // Seconds after midnight
+	P1               uint16
+	P2               uint16
+	ElevationNumber  uint16
+	P3               uint16
+	Thresholds       [16]uint16
+	P4               uint16
+	P5               uint16
+	P6               uint16
+	P7               uint16
+	P8               uint16
+	P9               uint16
+	P10              uint16
+	Version          uint8
+	SpotBlank        uint8
+	SymbologyOffset  uint32 // Halfwords from the start of the message header, 0 if there is no block
+	GraphicOffset    uint32
+	TabularOffset    uint32
+}
+
+func (d *ProductDescription) Lat() float64 {
+	return float64(d.Latitude) / 1000.0
+}
+
+func (d *ProductDescription) Lon() float64 {
+	return float64(d.Longitude) / 1000.0
+}
+
+// VolumeTime returns the start of the volume scan the product was made from
+func (d *ProductDescription) VolumeTime() time.Time {
+	return level2.JulianDateToTime(uint32(d.VolumeScanDate), d.VolumeScanTime*1000)
+}
+
+// Generated returns when the product was made
+func (d *ProductDescription) Generated() time.Time {
+	return level2.JulianDateToTime(uint32(d.GenerationDate), d.GenerationTime*1000)
+}
+
+// Compressed reports whether the product says everything after the PDB is bzip2 compressed, given by P8
+// of the digital products
+func (d *ProductDescription) Compressed() bool {
+	return d.P8 == 1
+}
+
+// ElevationAngle returns the elevation of single tilt products in degrees, held in P3
+func (d *ProductDescription) ElevationAngle() float32 {
+	return float32(int16(d.P3)) / 10.0
+}
@@ -0,0 +1,151 @@
+package level3
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+/*
+Product is a decoded Level III (NIDS) product. Blocks the product does not have are nil. Offsets in
+errors are from the start of the message header, after any WMO header, and inside the decompressed
+data for compressed products
+*/
+type Product struct {
+	WMOHeader   string // e.g. "SDUS54 KLIX 011200", empty if the file has no WMO header
+	AWIPSID     string // e.g. "N0BLIX"
+	Header      MessageHeader
+	Description ProductDescription
+	Symbology   *Symbology
+	Graphic     *Graphic
+	Tabular     *Tabular
+}
+
+func ParseLevel3(file io.ReadSeeker) (*Product, error) {
+	return ParseLevel3Context(context.Background(), file, level2.DefaultLimits)
+}
+
+/*
+ParseLevel3Context parses the product, giving up once ctx is cancelled. MaxRecordSize bounds the
+message and MaxDecompressedSize what a compressed product may decompress to
+*/
+func ParseLevel3Context(ctx context.Context, file io.ReadSeeker, limits level2.Limits) (*Product, error) {
+	product := Product{}
+
+	start, err := product.readWMOHeader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(file, binary.BigEndian, &product.Header); err != nil {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, 0, err)
+	}
+	if err := binary.Read(file, binary.BigEndian, &product.Description); err != nil {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, MessageHeaderSize, err)
+	}
+	if product.Description.Divider != -1 {
+		return nil, level2.NewError(level2.ErrUnknownFormat, MessageHeaderSize, fmt.Errorf("no PDB divider"))
+	}
+
+	// The rest of the message, which the block offsets point into
+	rest, err := io.ReadAll(file)
+	if err != nil {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, MessageHeaderSize+ProductDescriptionSize, err)
+	}
+	if limits.MaxRecordSize > 0 && int64(len(rest)) > limits.MaxRecordSize {
+		return nil, level2.NewError(level2.ErrSizeLimit, MessageHeaderSize+ProductDescriptionSize, nil)
+	}
+
+	if product.Description.Compressed() && bytes.HasPrefix(rest, []byte("BZh")) {
+		data, err := level2.DecompressWithLimit(bytes.NewReader(rest), len(rest), limits.MaxRecordSize, limits.MaxDecompressedSize)
+		if err != nil {
+			return nil, level2.RecordError(err, MessageHeaderSize+ProductDescriptionSize)
+		}
+		rest, _ = io.ReadAll(data)
+	}
+
+	// Offsets are from the message header so the header and PDB are put back in front
+	message := make([]byte, MessageHeaderSize+ProductDescriptionSize, MessageHeaderSize+ProductDescriptionSize+len(rest))
+	file.Seek(start, io.SeekStart)
+	if _, err := io.ReadFull(file, message); err != nil {
+		return nil, level2.NewError(level2.ErrTruncatedRecord, 0, err)
+	}
+	message = append(message, rest...)
+
+	blocks := []struct {
+		offset uint32
+		parse  func(data []byte, offset int64) error
+	}{
+		{product.Description.SymbologyOffset, product.parseSymbology},
+		{product.Description.GraphicOffset, product.parseGraphic},
+		{product.Description.TabularOffset, product.parseTabular},
+	}
+
+	for _, block := range blocks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if block.offset == 0 {
+			continue
+		}
+		offset := int64(block.offset) * 2
+		if offset < MessageHeaderSize+ProductDescriptionSize || offset >= int64(len(message)) {
+			return nil, level2.NewError(level2.ErrCorruptMessage, offset, fmt.Errorf("block offset outside of the message"))
+		}
+		if err := block.parse(message[offset:], offset); err != nil {
+			return nil, err
+		}
+	}
+
+	return &product, nil
+}
+
+/*
+Reads the WMO and AWIPS header lines that products from the NWS feeds start with, leaving the file at
+the message header and returning its position. Files without them are left where they were
+*/
+func (p *Product) readWMOHeader(file io.ReadSeeker) (int64, error) {
+	start, _ := file.Seek(0, io.SeekCurrent)
+
+	b := make([]byte, 64)
+	n, err := io.ReadFull(file, b)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, level2.NewError(level2.ErrTruncatedRecord, 0, err)
+	}
+	b = b[:n]
+	file.Seek(start, io.SeekStart)
+
+	// Product codes are small so a message header never starts with a letter or the SOH of an LDM product
+	if len(b) == 0 || (b[0] != 0x01 && (b[0] < 'A' || b[0] > 'Z')) {
+		return start, nil
+	}
+
+	// The WMO line, e.g. "SDUS54 KLIX 011200", is followed by the AWIPS ID line
+	pos := 0
+	wmo := false
+	for pos < len(b) {
+		end := bytes.IndexByte(b[pos:], '\n')
+		if end == -1 {
+			break
+		}
+		line := strings.TrimSpace(strings.Trim(string(b[pos:pos+end]), "\x01\r"))
+		pos += end + 1
+
+		if wmo {
+			p.AWIPSID = line
+			file.Seek(start+int64(pos), io.SeekStart)
+			return start + int64(pos), nil
+		}
+		if len(line) >= 18 && line[0] >= 'A' && line[0] <= 'Z' && line[6] == ' ' {
+			p.WMOHeader = line
+			wmo = true
+		}
+	}
+
+	return 0, level2.NewError(level2.ErrUnknownFormat, 0, fmt.Errorf("incomplete WMO header"))
+}
@@ -0,0 +1,420 @@
+package level3
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+// Options of the synthetic products the tests parse
+type testProduct struct {
+	code       int16
+	thresholds [16]uint16
+	layers     [][]byte   // Packets of each symbology layer
+	pages      [][]string // Tabular pages
+	compress   bool
+	wmo        bool
+}
+
+// Builds a product as the RPG sends it. The offsets are set the way the RPG sets them, halfwords from the message header
+func (p testProduct) bytes(t testing.TB) []byte {
+	t.Helper()
+
+	blocks := bytes.NewBuffer([]byte{})
+	offset := func() uint32 {
+		return uint32(MessageHeaderSize+ProductDescriptionSize+blocks.Len()) / 2
+	}
+
+	description := ProductDescription{
+		Divider:          -1,
+		Latitude:         30337,
+		Longitude:        -89825,
+		Height:           179,
+		Code:             p.code,
+		OperationalMode:  2,
+		VCP:              215,
+		VolumeScanNumber: 12,
+		VolumeScanDate:   19814, // 2024-03-31
+		VolumeScanTime:   3600,
+		GenerationDate:   19814,
+		GenerationTime:   3660,
+		P3:               5,
+		Thresholds:       p.thresholds,
+	}
+	if p.compress {
+		description.P8 = 1
+	}
+
+	if len(p.layers) > 0 {
+		description.SymbologyOffset = offset()
+		layers := bytes.NewBuffer([]byte{})
+		for _, layer := range p.layers {
+			binary.Write(layers, binary.BigEndian, int16(-1))
+			binary.Write(layers, binary.BigEndian, uint32(len(layer)))
+			layers.Write(layer)
+		}
+		binary.Write(blocks, binary.BigEndian, blockHeader{-1, 1, uint32(10 + layers.Len())})
+		binary.Write(blocks, binary.BigEndian, uint16(len(p.layers)))
+		blocks.Write(layers.Bytes())
+	}
+
+	header := MessageHeader{Code: p.code, Date: 19814, Time: 3665, Source: 1, Blocks: 3}
+
+	if len(p.pages) > 0 {
+		description.TabularOffset = offset()
+		pages := bytes.NewBuffer([]byte{})
+		binary.Write(pages, binary.BigEndian, header)
+		binary.Write(pages, binary.BigEndian, description)
+		binary.Write(pages, binary.BigEndian, int16(-1))
+		binary.Write(pages, binary.BigEndian, uint16(len(p.pages)))
+		for _, page := range p.pages {
+			for _, line := range page {
+				binary.Write(pages, binary.BigEndian, int16(len(line)))
+				pages.WriteString(line)
+			}
+			binary.Write(pages, binary.BigEndian, int16(-1))
+		}
+		binary.Write(blocks, binary.BigEndian, blockHeader{-1, 3, uint32(8 + pages.Len())})
+		blocks.Write(pages.Bytes())
+	}
+
+	rest := blocks.Bytes()
+	if p.compress {
+		compressed, err := level2.Compress(rest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rest = compressed
+	}
+	header.Length = uint32(MessageHeaderSize + ProductDescriptionSize + len(rest))
+
+	product := bytes.NewBuffer([]byte{})
+	if p.wmo {
+		product.WriteString("\x01\r\r\n123 \r\r\nSDUS54 KLIX 310101\r\r\nN0BLIX\r\r\n")
+	}
+	binary.Write(product, binary.BigEndian, header)
+	binary.Write(product, binary.BigEndian, description)
+	product.Write(rest)
+	return product.Bytes()
+}
+
+// Writes big endian values to a packet
+func packet(values ...any) []byte {
+	b := bytes.NewBuffer([]byte{})
+	for _, v := range values {
+		switch v := v.(type) {
+		case int:
+			binary.Write(b, binary.BigEndian, int16(v))
+		case string:
+			b.WriteString(v)
+		default:
+			binary.Write(b, binary.BigEndian, v)
+		}
+	}
+	return b.Bytes()
+}
+
+// A packet 16 of radials one degree wide, bin i of radial r at level (r+i)%256
+func digitalRadials(radials int, bins int) []byte {
+	b := packet(PacketDigitalRadial, 0, bins, 0, 0, 999, radials)
+	for r := 0; r < radials; r++ {
+		levels := make([]byte, bins)
+		for i := range levels {
+			levels[i] = byte(r + i)
+		}
+		b = append(b, packet(bins, r*10, 10)...)
+		b = append(b, levels...)
+		if bins%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	return b
+}
+
+func parse(t *testing.T, product []byte) *Product {
+	t.Helper()
+	p, err := ParseLevel3(bytes.NewReader(product))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestParseDigitalRadial(t *testing.T) {
+	for _, test := range []testProduct{
+		{code: CodeSuperResReflectivity},
+		{code: CodeSuperResReflectivity, compress: true, wmo: true},
+	} {
+		test.thresholds[0] = uint16(0xFFFF - 319) // -32.0 dBZ
+		test.thresholds[1] = 5
+		test.thresholds[2] = 254
+		test.layers = [][]byte{digitalRadials(360, 115)}
+
+		p := parse(t, test.bytes(t))
+
+		if test.wmo && (p.WMOHeader != "SDUS54 KLIX 310101" || p.AWIPSID != "N0BLIX") {
+			t.Errorf("WMO header %q %q", p.WMOHeader, p.AWIPSID)
+		}
+		if info, _ := p.Info(); info.Mnemonic != "N0B" {
+			t.Errorf("mnemonic %q", info.Mnemonic)
+		}
+		if want := time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC); !p.Description.VolumeTime().Equal(want) {
+			t.Errorf("volume time %v, want %v", p.Description.VolumeTime(), want)
+		}
+		if p.Description.Lat() != 30.337 || p.Description.Lon() != -89.825 || p.Description.ElevationAngle() != 0.5 {
+			t.Errorf("PDB %+v", p.Description)
+		}
+
+		if p.Symbology == nil || len(p.Symbology.Layers) != 1 || len(p.Symbology.Layers[0]) != 1 {
+			t.Fatal("symbology block not read")
+		}
+		radials := p.Symbology.Layers[0][0].(*RadialPacket)
+		if len(radials.Radials) != 360 || radials.Bins != 115 || radials.ScaleFactor != 0.999 {
+			t.Fatalf("%d radials of %d bins", len(radials.Radials), radials.Bins)
+		}
+		radial := radials.Radials[90]
+		if radial.StartAngle != 90 || radial.AngleDelta != 1 || len(radial.Levels) != 115 || radial.Levels[10] != 100 {
+			t.Errorf("radial 90 = %v %v %v", radial.StartAngle, radial.AngleDelta, radial.Levels[10])
+		}
+
+		levels, ok := p.DataLevels()
+		if !ok {
+			t.Fatal("no data levels")
+		}
+		if levels.Value(2) != -32 || levels.Value(66) != 0 || !math.IsNaN(float64(levels.Value(0))) {
+			t.Errorf("levels 2, 66 = %v, %v", levels.Value(2), levels.Value(66))
+		}
+		if levels.Masks[0] != level2.GateBelowThreshold || levels.Masks[100] != level2.GateValid {
+			t.Error("masks")
+		}
+	}
+}
+
+func TestDataLevels(t *testing.T) {
+	dvl := Product{Description: ProductDescription{Code: CodeDigitalVIL}}
+	// Linear scale 1 and offset 2 below level 20, then exp((n - 0.25) / 4)
+	dvl.Description.Thresholds = [16]uint16{0x4000, 0x4400, 20, 0x4800, 0x3800}
+	levels, _ := dvl.DataLevels()
+	if levels.Value(12) != 10 {
+		t.Errorf("DVL level 12 = %v, want 10", levels.Value(12))
+	}
+	if want := float32(math.Exp(19.75 / 4)); levels.Value(20) != want {
+		t.Errorf("DVL level 20 = %v, want %v", levels.Value(20), want)
+	}
+
+	eet := Product{Description: ProductDescription{Code: CodeEnhancedEchoTops}}
+	eet.Description.Thresholds = [16]uint16{0x7F, 1, 2, 0, 0, 0x80}
+	levels, _ = eet.DataLevels()
+	if levels.Value(0x85) != 3 || !eet.Topped(0x85) || eet.Topped(0x05) {
+		t.Errorf("EET level 0x85 = %v", levels.Value(0x85))
+	}
+
+	zdr := Product{Description: ProductDescription{Code: CodeDifferentialRefl}}
+	scale, offset := math.Float32bits(16), math.Float32bits(128)
+	zdr.Description.Thresholds = [16]uint16{uint16(scale >> 16), uint16(scale), uint16(offset >> 16), uint16(offset)}
+	levels, _ = zdr.DataLevels()
+	if levels.Value(160) != 2 {
+		t.Errorf("ZDR level 160 = %v, want 2", levels.Value(160))
+	}
+
+	if _, ok := (&Product{Description: ProductDescription{Code: CodeStormTracking}}).DataLevels(); ok {
+		t.Error("NST has no data levels")
+	}
+}
+
+func TestParseRasterAndDPA(t *testing.T) {
+	raster := packet(uint16(PacketRaster), uint16(0x8000), uint16(0xC0), -10, -20, 4, 0, 4, 0, 2, 2,
+		2, []byte{0x31, 0x22}, 2, []byte{0xF3, 0x00})
+	dpa := packet(PacketDPA, 0, 0, 131, 2, 4, []byte{100, 5, 31, 6}, 2, []byte{131, 0})
+
+	p := parse(t, testProduct{code: CodeDPA, layers: [][]byte{append(raster, dpa...)}}.bytes(t))
+
+	packets := p.Symbology.Layers[0]
+	r := packets[0].(*RasterPacket)
+	if r.IStart != -10 || r.XScale != 4 || len(r.Rows) != 2 || !bytes.Equal(r.Rows[0], []byte{1, 1, 1, 2, 2}) || len(r.Rows[1]) != 15 {
+		t.Errorf("raster %+v", r)
+	}
+	d := packets[1].(*DPAPacket)
+	if len(d.Rows) != 2 || len(d.Rows[0]) != 131 || d.Rows[0][99] != 5 || d.Rows[0][100] != 6 {
+		t.Errorf("DPA rows %d", len(d.Rows))
+	}
+}
+
+func TestParseStormTracking(t *testing.T) {
+	past := packet(PacketSpecialSymbol, 6, 40, 80, "!!")
+	track := packet(PacketLinkedVector, 8, 40, 80, 44, 84)
+	scit := packet(PacketSCITPast, len(past)+len(track))
+	scit = append(append(scit, past...), track...)
+
+	layer := packet(PacketStormID, 6, 40, 80, "A0")
+	layer = append(layer, scit...)
+	layer = append(layer, packet(PacketSTICircle, 6, 40, 80, 12)...)
+	layer = append(layer, packet(PacketHail, 10, 40, 80, 70, 30, 2)...)
+	layer = append(layer, packet(PacketPointFeature, 8, 40, 80, 3, 7)...)
+	layer = append(layer, packet(PacketTextValue, 8, 2, 0, 0, "AB")...)
+
+	pages := [][]string{
+		{"     STORM POSITION/FORECAST", "  A0   123/ 45"},
+		{"  B1   200/ 60"},
+	}
+	p := parse(t, testProduct{code: CodeStormTracking, layers: [][]byte{layer}, pages: pages}.bytes(t))
+
+	packets := p.Symbology.Layers[0]
+	if len(packets) != 6 {
+		t.Fatalf("%d packets", len(packets))
+	}
+	if s := packets[0].(*StormIDPacket); s.Storms[0] != (StormID{40, 80, "A0"}) {
+		t.Errorf("storm %+v", s.Storms)
+	}
+	if s := packets[1].(*SCITPacket); len(s.Packets) != 2 || s.Packets[1].(*LinkedVectorPacket).Points[1] != [2]int16{44, 84} {
+		t.Errorf("SCIT %+v", s)
+	}
+	if c := packets[2].(*CirclePacket); c.Circles[0].Radius != 12 {
+		t.Errorf("circle %+v", c)
+	}
+	if h := packets[3].(*HailPacket); h.Hail[0] != (Hail{40, 80, 70, 30, 2}) {
+		t.Errorf("hail %+v", h)
+	}
+	if f := packets[4].(*PointFeaturePacket); f.Features[0].Type != 3 {
+		t.Errorf("feature %+v", f)
+	}
+	if text := packets[5].(*TextPacket); text.Value != 2 || text.Text != "AB" {
+		t.Errorf("text %+v", text)
+	}
+
+	if p.Tabular == nil || len(p.Tabular.Pages) != 2 || p.Tabular.Pages[0][1] != pages[0][1] {
+		t.Fatalf("tabular %+v", p.Tabular)
+	}
+	if p.Tabular.Description.Code != CodeStormTracking {
+		t.Error("tabular PDB")
+	}
+}
+
+// Writes XDR values: int32, uint32 and float32 as words, strings with their length and padding
+func xdr(values ...any) []byte {
+	b := bytes.NewBuffer([]byte{})
+	for _, v := range values {
+		switch v := v.(type) {
+		case int:
+			binary.Write(b, binary.BigEndian, int32(v))
+		case string:
+			binary.Write(b, binary.BigEndian, uint32(len(v)))
+			b.WriteString(v)
+			b.Write(make([]byte, (4-len(v)%4)%4))
+		case []byte:
+			b.Write(v)
+		default:
+			binary.Write(b, binary.BigEndian, v)
+		}
+	}
+	return b.Bytes()
+}
+
+func TestParseGeneric(t *testing.T) {
+	radial := func(azimuth float32) []byte {
+		return xdr(1, azimuth, float32(0.5), float32(1), 3, "", 3, 10, 20, 30)
+	}
+	components := xdr(
+		1, 1, "SCALE", "scale=0.001",
+		2,
+		1, ComponentRadial, "DPR", float32(250), float32(0), 0, 2, radial(0), radial(1),
+		1, ComponentText, 0, "no rain",
+	)
+
+	for _, compress := range []bool{false, true} {
+		rest := components
+		compression := 0
+		if compress {
+			compressed, err := level2.Compress(components)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest, compression = compressed, 1
+		}
+
+		body := xdr("DPR", "Instantaneous Precipitation Rate", 176, 1, uint32(1711846800), "KLIX",
+			float32(30.337), float32(-89.825), float32(54), uint32(1711846800), uint32(1711846800), float32(0.5),
+			12, 2, 215, 1, compression, len(components), rest)
+		generic := packet(PacketGeneric, 0, uint32(len(body)), body)
+
+		p := parse(t, testProduct{code: CodeInstantPrecipRate, layers: [][]byte{generic}}.bytes(t))
+
+		g := p.Symbology.Layers[0][0].(*GenericPacket)
+		if g.Name != "DPR" || g.RadarName != "KLIX" || g.VCP != 215 || g.Lat != 30.337 {
+			t.Errorf("generic header %+v", g)
+		}
+		if !g.VolumeDate().Equal(time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC)) {
+			t.Errorf("volume time %v", g.VolumeDate())
+		}
+		if len(g.Parameters) != 1 || g.Parameters[0].Attributes != "scale=0.001" || len(g.Components) != 2 {
+			t.Fatalf("parameters %+v, %d components", g.Parameters, len(g.Components))
+		}
+		rc := g.Components[0].(*RadialComponent)
+		if rc.BinSize != 250 || len(rc.Radials) != 2 || rc.Radials[1].Azimuth != 1 || rc.Radials[1].Data[2] != 30 {
+			t.Errorf("radial component %+v", rc)
+		}
+		if text := g.Components[1].(*TextComponent); text.Text != "no rain" {
+			t.Errorf("text component %+v", text)
+		}
+	}
+}
+
+func TestParseCorrupt(t *testing.T) {
+	good := testProduct{code: CodeSuperResReflectivity, layers: [][]byte{digitalRadials(10, 20)}}.bytes(t)
+
+	setOffset := func(b []byte, offset uint32) []byte {
+		b = append([]byte{}, b...)
+		binary.BigEndian.PutUint32(b[MessageHeaderSize+90:], offset)
+		return b
+	}
+	// Sets a halfword of the radial packet, the radial count being at 28 and the bytes in the first radial at 30
+	setRadials := func(b []byte, at int, n uint16) []byte {
+		b = append([]byte{}, b...)
+		binary.BigEndian.PutUint16(b[MessageHeaderSize+ProductDescriptionSize+at:], n)
+		return b
+	}
+
+	tests := map[string]struct {
+		product []byte
+		kind    error
+	}{
+		"header":         {good[:50], level2.ErrTruncatedRecord},
+		"packets":        {good[:len(good)-30], level2.ErrTruncatedRecord},
+		"offset":         {setOffset(good, 1<<20), level2.ErrCorruptMessage},
+		"offset in PDB":  {setOffset(good, 10), level2.ErrCorruptMessage},
+		"radials":        {setRadials(good, 28, 30000), level2.ErrCorruptMessage},
+		"more radials":   {setRadials(good, 28, 11), level2.ErrTruncatedRecord},
+		"radial bytes":   {setRadials(good, 30, 0x9300), level2.ErrTruncatedRecord},
+		"WMO header":     {[]byte("SDUS54 KLIX"), level2.ErrUnknownFormat},
+		"no PDB divider": {append(good[:MessageHeaderSize:MessageHeaderSize], make([]byte, 200)...), level2.ErrUnknownFormat},
+	}
+
+	for name, test := range tests {
+		_, err := ParseLevel3(bytes.NewReader(test.product))
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%s: err = %v, want %v", name, err, test.kind)
+		}
+	}
+}
+
+func FuzzParseLevel3(f *testing.F) {
+	f.Add(testProduct{code: CodeSuperResReflectivity, layers: [][]byte{digitalRadials(4, 9)}, wmo: true}.bytes(f))
+	f.Add(testProduct{code: CodeStormTracking,
+		layers: [][]byte{packet(PacketSCITPast, 8, PacketSpecialSymbol, 4, 1, 2)},
+		pages:  [][]string{{"A0"}},
+	}.bytes(f))
+	f.Add(testProduct{code: CodeInstantPrecipRate,
+		layers: [][]byte{packet(PacketGeneric, 0, uint32(12), xdr("DPR", 1))},
+	}.bytes(f))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		limits := level2.Limits{MaxRecordSize: 1 << 20, MaxDecompressedSize: 1 << 20}
+		ParseLevel3Context(context.Background(), bytes.NewReader(data), limits)
+	})
+}
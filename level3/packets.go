@@ -0,0 +1,363 @@
+package level3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Packet is one of the display packets that make up the symbology and graphic blocks
+type Packet interface {
+	PacketCode() uint16
+}
+
+// Packet codes. Coordinates in packets are in 1/4 km from the radar unless the product says otherwise
+const (
+	PacketText              = 0x0001
+	PacketSpecialSymbol     = 0x0002
+	PacketLinkedVector      = 0x0006
+	PacketTextValue         = 0x0008
+	PacketLinkedVectorValue = 0x0009
+	PacketStormID           = 0x000F
+	PacketDigitalRadial     = 0x0010
+	PacketDPA               = 0x0011
+	PacketHail              = 0x0013
+	PacketPointFeature      = 0x0014
+	PacketSCITPast          = 0x0017
+	PacketSCITForecast      = 0x0018
+	PacketSTICircle         = 0x0019
+	PacketGeneric           = 0x001C
+	PacketRadial            = 0xAF1F // Run length encoded, 16 levels
+	PacketRaster            = 0xBA0F
+	PacketRasterAlt         = 0xBA07
+)
+
+// The most bins or radials a packet is expected to hold, well above the 1840 bins and 720 radials of super-res products
+const maxBins = 4096
+const maxRadials = 1440
+
+// RadialPacket holds packets 16 (8-bit levels) and AF1F (run length encoded 4-bit levels)
+type RadialPacket struct {
+	Code        uint16
+	FirstBin    int
+	Bins        int
+	ICenter     int16
+	JCenter     int16
+	ScaleFactor float32 // Display pixels per bin
+	Radials     []Radial
+}
+
+type Radial struct {
+	StartAngle float32 // degrees
+	AngleDelta float32 // degrees
+	Levels     []uint8 // Data level of each bin, see Product.DataLevels
+}
+
+// RasterPacket holds packets BA0F and BA07, rows of run length encoded 4-bit levels
+type RasterPacket struct {
+	Code   uint16
+	IStart int16
+	JStart int16
+	XScale int16
+	YScale int16
+	Rows   [][]uint8
+}
+
+// DPAPacket is the Digital Precipitation Array, rows of levels on the 1/4 LFM grid
+type DPAPacket struct {
+	BoxesPerRow int
+	Rows        [][]uint8
+}
+
+// TextPacket holds packets 1 and 8 (text) and 2 (special symbols, written as characters)
+type TextPacket struct {
+	Code  uint16
+	Value int16 // Colour level for packet 8, 0 otherwise
+	I     int16
+	J     int16
+	Text  string
+}
+
+// LinkedVectorPacket holds packets 6 and 9, a line through the points
+type LinkedVectorPacket struct {
+	Code   uint16
+	Value  int16 // Colour level for packet 9, 0 otherwise
+	Points [][2]int16
+}
+
+type StormID struct {
+	I  int16
+	J  int16
+	ID string
+}
+
+type StormIDPacket struct {
+	Storms []StormID
+}
+
+type Hail struct {
+	I       int16
+	J       int16
+	POH     int16 // Probability of hail, percent
+	POSH    int16 // Probability of severe hail, percent
+	MaxSize int16 // As sent
+}
+
+type HailPacket struct {
+	Hail []Hail
+}
+
+type PointFeature struct {
+	I         int16
+	J         int16
+	Type      int16 // e.g. mesocyclone or TVS, see the ICD for the codes of each product
+	Attribute int16 // Radius for mesocyclones
+}
+
+type PointFeaturePacket struct {
+	Features []PointFeature
+}
+
+// SCITPacket holds packets 23 and 24, the past and forecast tracks of a storm made of other packets
+type SCITPacket struct {
+	Code    uint16
+	Packets []Packet
+}
+
+type Circle struct {
+	I      int16
+	J      int16
+	Radius int16
+}
+
+type CirclePacket struct {
+	Circles []Circle
+}
+
+// UnknownPacket keeps the data of packets that are not decoded
+type UnknownPacket struct {
+	Code uint16
+	Data []byte
+}
+
+func (p *RadialPacket) PacketCode() uint16       { return p.Code }
+func (p *RasterPacket) PacketCode() uint16       { return p.Code }
+func (p *DPAPacket) PacketCode() uint16          { return PacketDPA }
+func (p *TextPacket) PacketCode() uint16         { return p.Code }
+func (p *LinkedVectorPacket) PacketCode() uint16 { return p.Code }
+func (p *StormIDPacket) PacketCode() uint16      { return PacketStormID }
+func (p *HailPacket) PacketCode() uint16         { return PacketHail }
+func (p *PointFeaturePacket) PacketCode() uint16 { return PacketPointFeature }
+func (p *SCITPacket) PacketCode() uint16         { return p.Code }
+func (p *CirclePacket) PacketCode() uint16       { return PacketSTICircle }
+func (p *UnknownPacket) PacketCode() uint16      { return p.Code }
+
+/*
+Parses the packets that fill data. offset is the position of data in the message. nested is set for
+the packets inside an SCIT packet, which can not hold another SCIT packet
+*/
+func parsePackets(data []byte, offset int64, nested bool) ([]Packet, error) {
+	r := newReader(data, offset)
+	packets := []Packet{}
+
+	for r.remaining() > 0 {
+		code := r.uint16()
+
+		var packet Packet
+		switch code {
+		case PacketDigitalRadial, PacketRadial:
+			packet = readRadialPacket(r, code)
+		case PacketRaster, PacketRasterAlt:
+			packet = readRasterPacket(r, code)
+		case PacketDPA:
+			packet = readDPAPacket(r)
+		case PacketGeneric:
+			packet = readGenericPacket(r)
+		case PacketSCITPast, PacketSCITForecast:
+			if nested {
+				return nil, r.fail(fmt.Errorf("SCIT packet inside another"))
+			}
+			fallthrough
+		default:
+			// Every other packet gives the length of its data
+			n := int(r.uint16())
+			data := newReader(r.bytes(n), r.offset-int64(n))
+			packet = readLengthPacket(data, code)
+			if data.err != nil {
+				return nil, data.err
+			}
+		}
+
+		if r.err != nil {
+			return nil, r.err
+		}
+		if packet == nil {
+			return nil, r.fail(fmt.Errorf("packet %#x could not be read", code))
+		}
+		packets = append(packets, packet)
+	}
+
+	return packets, nil
+}
+
+func readRadialPacket(r *reader, code uint16) Packet {
+	packet := RadialPacket{
+		Code:     code,
+		FirstBin: int(r.int16()),
+		Bins:     int(r.int16()),
+		ICenter:  r.int16(),
+		JCenter:  r.int16(),
+	}
+	packet.ScaleFactor = float32(r.int16()) / 1000.0
+	radials := int(r.int16())
+
+	if packet.Bins < 0 || packet.Bins > maxBins || radials < 0 || radials > maxRadials {
+		r.fail(fmt.Errorf("%d radials of %d bins", radials, packet.Bins))
+		return nil
+	}
+
+	for i := 0; i < radials && r.err == nil; i++ {
+		n := int(r.uint16())
+		radial := Radial{
+			StartAngle: float32(r.int16()) / 10.0,
+			AngleDelta: float32(r.int16()) / 10.0,
+		}
+
+		if code == PacketDigitalRadial {
+			// n is in bytes, padded to a halfword
+			levels := r.bytes(n + n%2)
+			radial.Levels = levels[:min(n, packet.Bins, len(levels))]
+		} else {
+			// n is in halfwords of runs
+			radial.Levels = expandRuns(r.bytes(n*2), packet.Bins)
+		}
+
+		packet.Radials = append(packet.Radials, radial)
+	}
+
+	return &packet
+}
+
+// Expands bytes of 4-bit run lengths and 4-bit levels, stopping at limit levels
+func expandRuns(runs []byte, limit int) []uint8 {
+	levels := []uint8{}
+	for _, b := range runs {
+		for i := 0; i < int(b>>4) && len(levels) < limit; i++ {
+			levels = append(levels, b&0x0F)
+		}
+	}
+	return levels
+}
+
+func readRasterPacket(r *reader, code uint16) Packet {
+	r.uint16() // Op flags
+	r.uint16()
+
+	packet := RasterPacket{
+		Code:   code,
+		IStart: r.int16(),
+		JStart: r.int16(),
+		XScale: r.int16(),
+	}
+	r.int16() // Fractional scales, reserved
+	packet.YScale = r.int16()
+	r.int16()
+	rows := int(r.int16())
+	r.int16() // Packing descriptor
+
+	if rows < 0 || rows > maxBins {
+		r.fail(fmt.Errorf("%d raster rows", rows))
+		return nil
+	}
+
+	for i := 0; i < rows && r.err == nil; i++ {
+		n := int(r.int16())
+		packet.Rows = append(packet.Rows, expandRuns(r.bytes(n), maxBins))
+	}
+
+	return &packet
+}
+
+func readDPAPacket(r *reader) Packet {
+	r.uint16() // Spares
+	r.uint16()
+	packet := DPAPacket{
+		BoxesPerRow: int(r.int16()),
+	}
+	rows := int(r.int16())
+
+	if packet.BoxesPerRow < 0 || packet.BoxesPerRow > maxBins || rows < 0 || rows > maxBins {
+		r.fail(fmt.Errorf("%d rows of %d boxes", rows, packet.BoxesPerRow))
+		return nil
+	}
+
+	for i := 0; i < rows && r.err == nil; i++ {
+		// Pairs of run length and level
+		runs := r.bytes(int(r.int16()))
+		row := []uint8{}
+		for j := 0; j+1 < len(runs); j += 2 {
+			for k := 0; k < int(runs[j]) && len(row) < packet.BoxesPerRow; k++ {
+				row = append(row, runs[j+1])
+			}
+		}
+		packet.Rows = append(packet.Rows, row)
+	}
+
+	return &packet
+}
+
+// Reads the packets that give the length of their data. r holds only the data of the packet
+func readLengthPacket(r *reader, code uint16) Packet {
+	switch code {
+	case PacketText, PacketTextValue, PacketSpecialSymbol:
+		packet := TextPacket{Code: code}
+		if code == PacketTextValue {
+			packet.Value = r.int16()
+		}
+		packet.I = r.int16()
+		packet.J = r.int16()
+		packet.Text = strings.TrimRight(string(r.bytes(r.remaining())), "\x00")
+		return &packet
+	case PacketLinkedVector, PacketLinkedVectorValue:
+		packet := LinkedVectorPacket{Code: code}
+		if code == PacketLinkedVectorValue {
+			packet.Value = r.int16()
+		}
+		for r.remaining() >= 4 {
+			packet.Points = append(packet.Points, [2]int16{r.int16(), r.int16()})
+		}
+		return &packet
+	case PacketStormID:
+		packet := StormIDPacket{}
+		for r.remaining() >= 6 {
+			packet.Storms = append(packet.Storms, StormID{I: r.int16(), J: r.int16(), ID: string(r.bytes(2))})
+		}
+		return &packet
+	case PacketHail:
+		packet := HailPacket{}
+		for r.remaining() >= 10 {
+			packet.Hail = append(packet.Hail, Hail{I: r.int16(), J: r.int16(), POH: r.int16(), POSH: r.int16(), MaxSize: r.int16()})
+		}
+		return &packet
+	case PacketPointFeature:
+		packet := PointFeaturePacket{}
+		for r.remaining() >= 8 {
+			packet.Features = append(packet.Features, PointFeature{I: r.int16(), J: r.int16(), Type: r.int16(), Attribute: r.int16()})
+		}
+		return &packet
+	case PacketSTICircle:
+		packet := CirclePacket{}
+		for r.remaining() >= 6 {
+			packet.Circles = append(packet.Circles, Circle{I: r.int16(), J: r.int16(), Radius: r.int16()})
+		}
+		return &packet
+	case PacketSCITPast, PacketSCITForecast:
+		packets, err := parsePackets(r.data, r.offset, true)
+		if err != nil {
+			r.err = err
+			return nil
+		}
+		return &SCITPacket{Code: code, Packets: packets}
+	}
+
+	return &UnknownPacket{Code: code, Data: r.data}
+}
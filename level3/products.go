@@ -0,0 +1,151 @@
+package level3
+
+import (
+	"math"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+type ProductInfo struct {
+	Mnemonic string // e.g. "N0B", the first three characters of the AWIPS ID
+	Name     string
+}
+
+// Product codes of the products this package knows the data levels or packets of
+const (
+	CodeStormTracking         = 58
+	CodeDPA                   = 81
+	CodeDigitalReflectivity   = 94
+	CodeDigitalVelocity       = 99
+	CodeDigitalVIL            = 134
+	CodeEnhancedEchoTops      = 135
+	CodeSuperResReflectivity  = 153
+	CodeSuperResVelocity      = 154
+	CodeDifferentialRefl      = 159
+	CodeCorrelationCoeff      = 161
+	CodeSpecificDiffPhase     = 163
+	CodeInstantPrecipRate     = 176
+	CodeLegacyReflectivity    = 19
+	CodeCompositeReflectivity = 37
+)
+
+var Products = map[int16]ProductInfo{
+	CodeLegacyReflectivity:    {"N0R", "Base Reflectivity"},
+	CodeCompositeReflectivity: {"NCR", "Composite Reflectivity"},
+	CodeStormTracking:         {"NST", "Storm Tracking Information"},
+	CodeDPA:                   {"DPA", "Digital Precipitation Array"},
+	CodeDigitalReflectivity:   {"N0Q", "Digital Base Reflectivity"},
+	CodeDigitalVelocity:       {"N0U", "Digital Base Velocity"},
+	CodeDigitalVIL:            {"DVL", "Digital Vertically Integrated Liquid"},
+	CodeEnhancedEchoTops:      {"EET", "Enhanced Echo Tops"},
+	CodeSuperResReflectivity:  {"N0B", "Super-Res Digital Base Reflectivity"},
+	CodeSuperResVelocity:      {"N0G", "Super-Res Digital Base Velocity"},
+	CodeDifferentialRefl:      {"N0X", "Digital Differential Reflectivity"},
+	CodeCorrelationCoeff:      {"N0C", "Digital Correlation Coefficient"},
+	CodeSpecificDiffPhase:     {"N0K", "Digital Specific Differential Phase"},
+	CodeInstantPrecipRate:     {"DPR", "Digital Instantaneous Precipitation Rate"},
+}
+
+// Info returns the name of the product, ok is false for products not in Products
+func (p *Product) Info() (ProductInfo, bool) {
+	info, ok := Products[p.Description.Code]
+	return info, ok
+}
+
+/*
+DataLevels maps each 8-bit data level of a digital product to its physical value, worked out from
+the thresholds in the PDB. Masks marks the levels that are below threshold or flagged (range folded
+for velocity) instead of data, and their value is NaN
+*/
+type DataLevels struct {
+	Values [256]float32
+	Masks  [256]level2.GateMask
+}
+
+func (l *DataLevels) Value(level uint8) float32 {
+	return l.Values[level]
+}
+
+/*
+DataLevels returns the data levels of the digital products that send values with packet 16, with ok
+false for other products. Units are those of the product: dBZ, m/s, kg/m², kft, dB, or deg/km
+*/
+func (p *Product) DataLevels() (*DataLevels, bool) {
+	t := p.Description.Thresholds
+	levels := DataLevels{}
+
+	// Levels 0 and 1 are reserved on all of them
+	levels.Masks[0] = level2.GateBelowThreshold
+	levels.Masks[1] = level2.GateRangeFolded
+
+	switch p.Description.Code {
+	case CodeDigitalReflectivity, CodeDigitalVelocity, CodeSuperResReflectivity, CodeSuperResVelocity:
+		// Minimum and increment in tenths, then the number of levels
+		first := float32(int16(t[0])) / 10.0
+		inc := float32(int16(t[1])) / 10.0
+		for i := 2; i < 256; i++ {
+			levels.Values[i] = first + float32(i-2)*inc
+		}
+	case CodeDigitalVIL:
+		// Linear below the log start level and logarithmic above it, the coefficients as 16-bit floats
+		linScale, linOffset := float16(t[0]), float16(t[1])
+		logStart := int(t[2])
+		logScale, logOffset := float16(t[3]), float16(t[4])
+		for i := 2; i < 256; i++ {
+			if i < logStart {
+				levels.Values[i] = float32((float64(i) - linOffset) / linScale)
+			} else {
+				levels.Values[i] = float32(math.Exp((float64(i) - logOffset) / logScale))
+			}
+		}
+	case CodeEnhancedEchoTops:
+		// The top bit of each level marks tops that were above the highest tilt, see Topped
+		mask, scale, offset := t[0], float32(t[1]), float32(t[2])
+		if scale == 0 {
+			return nil, false
+		}
+		for i := 2; i < 256; i++ {
+			levels.Values[i] = float32(uint16(i)&mask)/scale - offset
+		}
+	case CodeDifferentialRefl, CodeCorrelationCoeff, CodeSpecificDiffPhase:
+		// Scale and offset as 32-bit floats over two halfwords each
+		scale := math.Float32frombits(uint32(t[0])<<16 | uint32(t[1]))
+		offset := math.Float32frombits(uint32(t[2])<<16 | uint32(t[3]))
+		if scale == 0 {
+			return nil, false
+		}
+		for i := 2; i < 256; i++ {
+			levels.Values[i] = (float32(i) - offset) / scale
+		}
+	default:
+		return nil, false
+	}
+
+	levels.Values[0] = float32(math.NaN())
+	levels.Values[1] = float32(math.NaN())
+
+	return &levels, true
+}
+
+// Topped reports whether an EET level is for an echo top above the highest tilt, so higher than its value
+func (p *Product) Topped(level uint8) bool {
+	if p.Description.Code != CodeEnhancedEchoTops || level < 2 {
+		return false
+	}
+	return uint16(level)&p.Description.Thresholds[5] != 0
+}
+
+// Decodes the 16-bit floats of the DVL thresholds: a sign bit, 5 bits of exponent and 10 of fraction
+func float16(v uint16) float64 {
+	frac := float64(v & 0x03FF)
+	exp := int(v>>10) & 0x1F
+
+	value := frac / (1 << 9)
+	if exp != 0 {
+		value = math.Pow(2, float64(exp-16)) * (1 + frac/(1<<10))
+	}
+	if v&0x8000 != 0 {
+		value = -value
+	}
+	return value
+}
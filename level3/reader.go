@@ -0,0 +1,78 @@
+package level3
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+/*
+Reads big endian values from a block. The first read past the end sets err and every read after it
+returns zero, so a packet can be read field by field and err checked once at the end
+*/
+type reader struct {
+	data   []byte
+	pos    int
+	offset int64 // Position in the message of the next byte
+	err    error
+}
+
+func newReader(data []byte, offset int64) *reader {
+	return &reader{data: data, offset: offset}
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = level2.NewError(level2.ErrTruncatedRecord, r.offset, fmt.Errorf("%d bytes wanted, %d left", n, len(r.data)-r.pos))
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	r.offset += int64(n)
+	return b
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) int16() int16 {
+	return int16(r.uint16())
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *reader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *reader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *reader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+// Records a corrupt data error at the current position, unless there is an error already
+func (r *reader) fail(err error) error {
+	if r.err == nil {
+		r.err = level2.NewError(level2.ErrCorruptMessage, r.offset, err)
+	}
+	return r.err
+}
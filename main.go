@@ -1,53 +1,91 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 	"github.com/TheRangiCrew/NEXRAD-GO/server"
 )
 
+const usage = `usage:
+  nexrad-go                                   ingest the real-time feed
+  nexrad-go split [-volume n] archive dir     split an archive into the chunks of the feed
+  nexrad-go replay [-speed x] path...         replay chunks and archives through the ingest pipeline
+`
+
 func main() {
-	// filename := "./test/chunks/20240401-214657-002-I"
-	// filename := "KLCH20240123_003612_V06"
-	// filename := "TATL20240421_001129_V08"
-
-	// file, err := os.Open(filename)
-	// if err != nil {
-	// 	panic(err)
-	// }
-
-	// tdwr.ParseTDWR(file)
-	// l2Radar, err := nexrad.ParseNexrad(file)
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// fmt.Println(string(l2Radar.ElevationScans[1].M31[2].VolumeData.DataName[:]))
-
-	// server.StartServer()
-
-	files := []string{
-		"20240401-214657-001-S",
-		"20240401-214657-002-I",
-		"20240401-214657-003-I",
-		"20240401-214657-004-I",
-		"20240401-214657-005-I",
-		"20240401-214657-006-I",
-		"20240401-214657-007-I",
-		"20240401-214657-008-I",
-		"20240401-214657-009-I",
-		"20240401-214657-010-I",
-	}
-	for _, filename := range files {
-		file, err := os.Open("./test/chunks/" + filename)
-		if err != nil {
-			panic(err)
-		}
+	if len(os.Args) < 2 {
+		server.Init(true)
+		server.Serve()
+		return
+	}
+
+	switch os.Args[1] {
+	case "split":
+		split(os.Args[2:])
+	case "replay":
+		replay(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// Writes the chunks of an archive to dir as the feed keys them, e.g. dir/KHDX/585/20240401-214657-001-S
+func split(args []string) {
+	flags := flag.NewFlagSet("split", flag.ExitOnError)
+	volumeID := flags.Int("volume", 1, "volume ID to give the chunks, 1 to 999")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
 
-		chunkData, err := server.FilenameToChunkData(filename)
-		if err != nil {
-			panic(err)
+	chunks, err := level2.SplitArchive(file, *volumeID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, c := range chunks {
+		name := filepath.Join(flags.Arg(1), filepath.FromSlash(c.Path()))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(name, c.Data, 0644); err != nil {
+			log.Fatal(err)
 		}
-		server.Init(true)
-		server.HandleFile(file, *chunkData)
 	}
+	log.Printf("Wrote %d chunks of %s\n", len(chunks), chunks[0].Site)
+}
+
+// Replays chunks and archives at real time, speed times faster, or as fast as they can be handled
+func replay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := flags.Float64("speed", 1, "how many times faster than real time to replay, 0 for as fast as possible")
+	flags.Parse(args)
+	if flags.NArg() == 0 || *speed < 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	chunks, err := server.LoadReplay(flags.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(chunks) == 0 {
+		log.Fatal("nothing to replay")
+	}
+
+	server.Init(true)
+	server.Replay(chunks, *speed)
 }
@@ -2,16 +2,19 @@ module github.com/TheRangiCrew/NEXRAD-GO/server
 
 go 1.22.1
 
-replace github.com/TheRangiCrew/NEXRAD-GO/level2/ => ../level2/
+replace github.com/TheRangiCrew/NEXRAD-GO/level2 => ../level2/
 
 replace github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad => ../level2/nexrad
 
+replace github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr => ../level2/tdwr
+
 require (
 	github.com/aws/aws-sdk-go-v2 v1.26.1
 	github.com/aws/aws-sdk-go-v2/feature/s3/manager v1.16.17
 )
 
 require (
+	github.com/dsnet/compress v0.0.1 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 )
@@ -38,6 +41,7 @@ require (
 
 require (
 	github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad v0.0.0-20240422075631-1de2515c31c9
+	github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr v0.0.0-00010101000000-000000000000
 	github.com/aws/aws-sdk-go-v2/config v1.27.13
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.53.2
 	github.com/aws/aws-sdk-go-v2/service/sqs v1.31.4
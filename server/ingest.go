@@ -1,18 +1,18 @@
-package main
+package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 	nexrad "github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad"
+	"github.com/TheRangiCrew/NEXRAD-GO/level2/tdwr"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
@@ -37,6 +37,15 @@ type Payload struct {
 
 var scanChan chan Scan
 
+// Puts the chunks of each volume back in order to report the ones that never arrive
+var assembler = &level2.Assembler{
+	Limits:     level2.DefaultLimits,
+	EventsOnly: true,
+}
+
+// How long a single chunk may take to parse before it is abandoned
+const ParseTimeout = 30 * time.Second
+
 func Ingest(scanCh chan Scan) {
 	scanChan = scanCh
 	for {
@@ -81,53 +90,82 @@ func HandlePayload(payload Payload) {
 		return
 	}
 
-	data := bytes.NewReader(object)
+	HandleFile(bytes.NewReader(object), *chunkData)
+}
+
+func HandleFile(data io.ReadSeeker, chunkData ChunkFileData) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), ParseTimeout)
+	defer cancel()
 
-	l2Radar, err := nexrad.ParseNexrad(data)
+	trackChunk(data, chunkData)
+
+	volume, err := parseVolume(ctx, data, chunkData.Site)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
 	if chunkData.ChunkType == "S" {
-		tdwr, _ := level2.IsTDWRArchive(data)
-		if tdwr {
-			return
-		}
-		err := NewVolume(l2Radar, *chunkData)
+		err := NewVolume(volume, chunkData)
 		if err != nil {
 			log.Println(err)
 			return
 		}
 	} else {
-		AddToVolume(l2Radar, *chunkData)
+		AddToVolume(volume, chunkData)
 	}
 }
 
-func HandleFile(data io.ReadSeeker, chunkData ChunkFileData) {
-
-	l2Radar, err := nexrad.ParseNexrad(data)
+// Passes the chunk to the assembler and logs the chunks it gives up on
+func trackChunk(data io.ReadSeeker, chunkData ChunkFileData) {
+	raw, err := io.ReadAll(data)
+	data.Seek(0, io.SeekStart)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
-	if chunkData.ChunkType == "S" {
-		tdwr, _ := level2.IsTDWRArchive(data)
-		if tdwr {
-			return
+	events, err := assembler.Add(chunkData, raw)
+	if err != nil {
+		log.Println(err)
+	}
+	for _, event := range events {
+		switch event.Type {
+		case level2.ChunksMissing:
+			log.Printf("Volume %d of %s is missing chunks %v\n", event.Key.VolumeID, event.Key.Site, event.Missing)
+		case level2.VolumeComplete:
+			log.Printf("Volume %d of %s completed with %d chunks missing\n", event.Key.VolumeID, event.Key.Site, len(event.Missing))
 		}
-		err := NewVolume(l2Radar, chunkData)
+	}
+}
+
+// Parses a NEXRAD or TDWR file or chunk. The site picks the parser for chunks without a volume header
+func parseVolume(ctx context.Context, data io.ReadSeeker, site string) (*level2.Volume, error) {
+	format, err := level2.DetectSiteFormat(data, site)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format.Parser {
+	case level2.ParserNexrad:
+		radar, err := nexrad.ParseNexradContext(ctx, data, level2.DefaultLimits)
 		if err != nil {
-			log.Println(err)
-			return
+			return nil, err
 		}
-	} else {
-		AddToVolume(l2Radar, chunkData)
+		return radar.Volume(), nil
+	case level2.ParserTDWR:
+		radar, err := tdwr.ParseTDWRContext(ctx, data, level2.DefaultLimits)
+		if err != nil {
+			return nil, err
+		}
+		return radar.Volume(), nil
 	}
+
+	return nil, level2.NewError(level2.ErrUnknownFormat, 0, nil)
 }
 
-func NewVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) error {
+func NewVolume(l2Radar *level2.Volume, chunkData ChunkFileData) error {
 
 	if l2Radar.ICAO == "" {
 		return errors.New("radar data did not contain a valid ICAO")
@@ -139,15 +177,15 @@ func NewVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) error {
 	}
 
 	if site == nil {
-		site, err = AddSite(l2Radar.ICAO)
+		site, err = AddSite(l2Radar.ICAO, l2Radar.Site)
 		if err != nil {
 			return err
 		}
 	}
 
 	vcp := site.VCP
-	if l2Radar.VCP != nil {
-		vcp = int(l2Radar.VCP.Header.PatternNumber)
+	if l2Radar.VCP != 0 {
+		vcp = l2Radar.VCP
 	}
 
 	volume := Volume{
@@ -168,7 +206,7 @@ func NewVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) error {
 		return err
 	}
 
-	scans := NexradToScans(l2Radar)
+	scans := VolumeToScans(l2Radar)
 	if len(scans) > 0 {
 		fmt.Println("This volume already has scans")
 	}
@@ -176,7 +214,7 @@ func NewVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) error {
 	return nil
 }
 
-func AddToVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) (*Scan, error) {
+func AddToVolume(l2Radar *level2.Volume, chunkData ChunkFileData) (*Scan, error) {
 	if l2Radar.ICAO == "" {
 		return nil, errors.New("radar data did not contain a valid ICAO")
 	}
@@ -187,7 +225,7 @@ func AddToVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) (*Scan, error)
 	}
 
 	if site == nil {
-		site, err = AddSite(l2Radar.ICAO)
+		site, err = AddSite(l2Radar.ICAO, l2Radar.Site)
 		if err != nil {
 			return nil, err
 		}
@@ -203,7 +241,8 @@ func AddToVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) (*Scan, error)
 		return nil, fmt.Errorf("no volume found")
 	}
 
-	newScans := NexradToScans(l2Radar)
+	TagSupplementalSweeps(volumeID, l2Radar)
+	newScans := VolumeToScans(l2Radar)
 
 	scans := Scans()
 
@@ -217,7 +256,7 @@ func AddToVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) (*Scan, error)
 			scanIndex = len(*scans) - 1
 		} else {
 			currentScan = &(*scans)[scanIndex]
-			*currentScan.Gates = append(*currentScan.Gates, *newScan.Gates...)
+			currentScan.Gates = append(currentScan.Gates, newScan.Gates...)
 		}
 
 		if newScan.EOE {
@@ -228,59 +267,30 @@ func AddToVolume(l2Radar *nexrad.Nexrad, chunkData ChunkFileData) (*Scan, error)
 		}
 
 		if (currentScan.EOE || currentScan.EOV) && volume.VCP != 0 {
-			fmt.Printf("%s on elevation %d completed\n", currentScan.ProductType, currentScan.ElevationNumber)
+			if currentScan.Supplemental {
+				fmt.Printf("%s on supplemental elevation %d (repeating %d) completed\n", currentScan.ProductType, currentScan.ElevationNumber, currentScan.BaseElevation)
+			} else {
+				fmt.Printf("%s on elevation %d completed\n", currentScan.ProductType, currentScan.ElevationNumber)
+			}
 			scanChan <- *currentScan
+			// currentScan points into scans so it is no longer this scan once removed
+			eov := currentScan.EOV
 			fmt.Printf("Removing scan for %s\n", l2Radar.ICAO)
 			_, err := RemoveScan(scanIndex, scans)
 			if err != nil {
 				return nil, err
 			}
+			if eov {
+				ForgetVolumeAngles(volumeID)
+			}
 		}
 	}
 
 	return nil, nil
 }
 
-type ChunkFileData struct {
-	Site      string
-	InitTime  time.Time
-	Number    int
-	ChunkType string
-}
-
-func FilenameToChunkData(filename string) (*ChunkFileData, error) {
-	segments := strings.Split(filename, "-")
-
-	dateString := segments[0]
-	timeString := segments[1]
-
-	year, err := strconv.Atoi(dateString[0:4])
-	month, err := strconv.Atoi(dateString[4:6])
-	day, err := strconv.Atoi(dateString[6:])
-
-	hour, err := strconv.Atoi(timeString[0:2])
-	minute, err := strconv.Atoi(timeString[2:4])
-	second, err := strconv.Atoi(timeString[4:])
-
-	if err != nil {
-		return nil, err
-	}
-
-	time := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
-
-	number, err := strconv.Atoi(segments[2])
-	if err != nil {
-		return nil, err
-	}
-
-	chunkType := segments[3]
-
-	return &ChunkFileData{
-		InitTime:  time,
-		Number:    number,
-		ChunkType: chunkType,
-	}, nil
-}
+// The name and number of a chunk, see level2.FilenameToChunkData
+type ChunkFileData = level2.ChunkFileData
 
 func PayloadToChunkData(payload Payload) (*ChunkFileData, error) {
 
@@ -291,6 +301,7 @@ func PayloadToChunkData(payload Payload) (*ChunkFileData, error) {
 
 	return &ChunkFileData{
 		Site:      payload.SiteID,
+		VolumeID:  payload.VolumeID,
 		InitTime:  t,
 		Number:    payload.ChunkID,
 		ChunkType: payload.ChunkType,
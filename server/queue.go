@@ -1,4 +1,4 @@
-package main
+package server
 
 // Queue represents a FIFO queue
 type Queue struct {
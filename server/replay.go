@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
+)
+
+/*
+LoadReplay reads the chunks and archives in the given files and directories. Chunks are named as the
+feed names them, with the site and volume ID read from the directories they are in when there are
+any, e.g. "KHDX/585/20240401-214657-001-S". Archives are split into the chunks the feed would have
+sent them as. Files that are neither are skipped
+*/
+func LoadReplay(paths ...string) ([]level2.Chunk, error) {
+	chunks := []level2.Chunk{}
+	volumeIDs := map[string]int{}
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, name)
+			if err != nil || rel == "." {
+				rel = filepath.Base(name)
+			}
+
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+
+			if chunkData, err := level2.FilenameToChunkData(filepath.ToSlash(rel)); err == nil {
+				chunks = append(chunks, level2.Chunk{ChunkFileData: *chunkData, Data: data})
+				return nil
+			}
+
+			// Volume IDs of archives count up for each site as the feed's do
+			archive, err := level2.SplitArchive(bytes.NewReader(data), 0)
+			if err != nil {
+				log.Printf("Skipping %s: %s\n", name, err)
+				return nil
+			}
+			site := archive[0].Site
+			volumeIDs[site] = volumeIDs[site]%999 + 1
+			for _, c := range archive {
+				c.VolumeID = volumeIDs[site]
+				chunks = append(chunks, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Chunks named without their site get it from the volume header of their S chunk
+	sites := map[time.Time]string{}
+	for _, c := range chunks {
+		if c.ChunkType != level2.ChunkStart || c.Site != "" {
+			continue
+		}
+		header, err := level2.GetVolumeHeader(bytes.NewReader(c.Data))
+		if err == nil {
+			sites[c.InitTime] = string(bytes.TrimSpace(header.ICAO[:]))
+		}
+	}
+	for i := range chunks {
+		if chunks[i].Site == "" {
+			chunks[i].Site = sites[chunks[i].InitTime]
+		}
+	}
+
+	return chunks, nil
+}
+
+// A chunk with when the feed would have sent it
+type replayChunk struct {
+	level2.Chunk
+	sent time.Time
+}
+
+/*
+Replay hands the chunks to HandleFile in order of volume time and chunk number, as the feed would
+have sent them, and uploads the scans they complete. They are sent speed times faster than they
+were collected, or as fast as they can be handled when speed is 0. Replay returns once every chunk
+has been handled and every scan uploaded
+*/
+func Replay(chunks []level2.Chunk, speed float64) {
+	replay := make([]replayChunk, len(chunks))
+	for i, c := range chunks {
+		replay[i] = replayChunk{Chunk: c, sent: c.InitTime}
+		last, err := level2.LastRadialTime(c.Data, level2.DefaultLimits)
+		if err == nil && !last.IsZero() {
+			replay[i].sent = last
+		}
+	}
+	sort.SliceStable(replay, func(i, j int) bool {
+		a, b := replay[i], replay[j]
+		if !a.InitTime.Equal(b.InitTime) {
+			return a.InitTime.Before(b.InitTime)
+		}
+		if a.Site != b.Site {
+			return a.Site < b.Site
+		}
+		return a.Number < b.Number
+	})
+
+	scanChan = make(chan Scan)
+	uploaded := make(chan struct{})
+	go func() {
+		Upload(scanChan)
+		close(uploaded)
+	}()
+
+	start := time.Now()
+	var first, previous time.Time
+	for _, c := range replay {
+		// Volumes of different sites overlap, so a chunk is never sent before the one in front of it
+		sent := c.sent
+		if sent.Before(previous) {
+			sent = previous
+		}
+		if first.IsZero() {
+			first = sent
+		}
+		previous = sent
+
+		if speed > 0 {
+			time.Sleep(time.Duration(float64(sent.Sub(first))/speed) - time.Since(start))
+		}
+
+		log.Printf("Replaying %s %s\n", c.Site, c.Filename())
+		HandleFile(bytes.NewReader(c.Data), c.ChunkFileData)
+	}
+
+	close(scanChan)
+	<-uploaded
+}
@@ -1,36 +1,39 @@
-package main
+package server
 
 import (
+	"encoding/json"
 	"errors"
 	"sort"
 	"sync"
 	"time"
 
-	nexrad "github.com/TheRangiCrew/NEXRAD-GO/level2/nexrad"
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 )
 
 type Scan struct {
-	ICAO               string       `json:"icao"`
-	ProductType        string       `json:"productType"`
-	ElevationAngle     float32      `json:"elevationAngle"`
-	ElevationNumber    int          `json:"elevationNumber"`
-	StartAzimuth       float32      `json:"startAngle"`
-	StartAzimuthNumber int          `json:"-"`
-	AzimuthResolution  float32      `json:"azimuthResolution"`
-	StartRange         float32      `json:"startRange"`
-	GateInterval       float32      `json:"gateInterval"`
-	Lat                float32      `json:"lat"`
-	Lon                float32      `json:"lon"`
-	Gates              *[][]float32 `json:"gates"`
-	InitTime           time.Time    `json:"init_time"`
-	EOE                bool         `json:"-"` // End of elevation
-	EOV                bool         `json:"-"` // EOV
+	ICAO               string         `json:"icao"`
+	ProductType        string         `json:"productType"`
+	ElevationAngle     float32        `json:"elevationAngle"`
+	ElevationNumber    int            `json:"elevationNumber"`
+	Supplemental       bool           `json:"supplemental"`                  // A SAILS or MRLE low level update
+	BaseElevation      int            `json:"baseElevationNumber,omitempty"` // Elevation number the supplemental scan repeats
+	StartAzimuth       float32        `json:"startAngle"`
+	StartAzimuthNumber int            `json:"-"`
+	AzimuthResolution  float32        `json:"azimuthResolution"`
+	StartRange         float32        `json:"startRange"`
+	GateInterval       float32        `json:"gateInterval"`
+	Lat                float32        `json:"lat"`
+	Lon                float32        `json:"lon"`
+	Gates              []level2.Gates `json:"-"` // Raw words of each ray, converted to "gates" when marshalled
+	InitTime           time.Time      `json:"init_time"`
+	EOE                bool           `json:"-"` // End of elevation
+	EOV                bool           `json:"-"` // EOV
 }
 
 type MomentBlocks struct {
 	AzimuthAngle  float32
 	AzimuthNumber int
-	Gates         []float32
+	Gates         level2.Gates
 }
 
 type Moment struct {
@@ -46,6 +49,8 @@ type Elevation struct {
 	Lat               float32
 	Lon               float32
 	Number            int
+	Supplemental      bool
+	Base              int
 	Moments           map[string]*Moment
 }
 
@@ -64,6 +69,44 @@ func Scans() *[]Scan {
 	return queue
 }
 
+/*
+MarshalJSON converts the raw gate words to values as the scan is sent. Runs of the same value are
+sent as the first value then -1000 minus the length of the rest of the run
+*/
+func (s Scan) MarshalJSON() ([]byte, error) {
+	type scan Scan
+
+	gates := make([][]float32, len(s.Gates))
+	for i, g := range s.Gates {
+		gates[i] = compressGates(GatesFromMoment(g))
+	}
+
+	return json.Marshal(struct {
+		scan
+		Gates [][]float32 `json:"gates"`
+	}{scan(s), gates})
+}
+
+func compressGates(gates []float32) []float32 {
+	compressed := []float32{}
+
+	mask := 0
+	for _, g := range gates {
+		if len(compressed) > 0 && g == compressed[len(compressed)-1] {
+			mask++
+		} else {
+			if mask > 0 {
+				compressed = append(compressed, float32(-1000-mask))
+				mask = 0
+			} else {
+				compressed = append(compressed, g)
+			}
+		}
+	}
+
+	return compressed
+}
+
 func RemoveScan(index int, scans *[]Scan) (Scan, error) {
 	if index >= len(*scans) {
 		return Scan{}, errors.New("index out of bounds")
@@ -76,69 +119,62 @@ func RemoveScan(index int, scans *[]Scan) (Scan, error) {
 	return removedScan, nil
 }
 
-func NexradToScans(l2Radar *nexrad.Nexrad) []Scan {
+// VolumeToScans splits the volume into a scan per moment and elevation. Split cuts become one elevation
+func VolumeToScans(v *level2.Volume) []Scan {
 
 	elevations := map[int]*Elevation{}
 
 	eoe := false
 	eov := false
 
-	for k, e := range l2Radar.ElevationScans {
-		elevations[k] = &Elevation{
-			Number:  k,
-			Moments: map[string]*Moment{},
+	for _, sweep := range v.MergeSplitCuts() {
+		elevation := &Elevation{
+			Number:            sweep.Number,
+			Angle:             sweep.ElevationAngle,
+			AzimuthResolution: sweep.AzimuthResolution,
+			Supplemental:      sweep.Supplemental,
+			Base:              sweep.Base,
+			Moments:           map[string]*Moment{},
 		}
+		elevations[sweep.Number] = elevation
 
-		elevation := elevations[k]
-
-		var angleSum float32 = 0.0
-		n := 0
-
-		for _, scan := range e.M31 {
-			if elevation.Lat == 0 && elevation.Lon == 0 {
-				elevation.Lat = scan.VolumeData.Lat
-				elevation.Lon = scan.VolumeData.Long
-			}
-			if elevation.AzimuthResolution == 0 {
-				elevation.AzimuthResolution = float32(scan.Header.AzimuthResolution) / 2.0
-			}
+		if v.Site != nil {
+			elevation.Lat = float32(v.Site.Lat)
+			elevation.Lon = float32(v.Site.Lon)
+		}
 
-			angleSum += scan.Header.ElevationAngle
-			n++
-			for key, m := range scan.MomentData {
-				moment := elevation.Moments[string(key)]
+		for _, ray := range sweep.Rays {
+			for key, m := range ray.Moments {
+				moment := elevation.Moments[key]
 
 				if moment == nil {
-					elevation.Moments[string(key)] = &Moment{
-						StartRange:   float32(m.Range) / 1000.0,
-						GateInterval: float32(m.RangeSampleInterval) / 1000.0,
-						Name:         string(key),
+					elevation.Moments[key] = &Moment{
+						StartRange:   m.FirstGate,
+						GateInterval: m.GateInterval,
+						Name:         key,
 						Blocks: []MomentBlocks{
 							{
-								AzimuthAngle:  scan.Header.AzimuthAngle,
-								AzimuthNumber: int(scan.Header.AzimuthNumber),
-								Gates:         m.Data,
+								AzimuthAngle:  ray.Azimuth,
+								AzimuthNumber: ray.AzimuthNumber,
+								Gates:         m.Gates,
 							},
 						},
 					}
 				} else {
 					moment.Blocks = append(moment.Blocks, MomentBlocks{
-						AzimuthAngle: scan.Header.AzimuthAngle,
-						Gates:        m.Data,
+						AzimuthAngle: ray.Azimuth,
+						Gates:        m.Gates,
 					})
 				}
 			}
 
-			if scan.Header.RadialStatus == 2 {
+			if ray.EndOfElevation() {
 				eoe = true
 			}
-			if scan.Header.RadialStatus == 4 {
-				eoe = true
+			if ray.EndOfVolume() {
 				eov = true
 			}
 		}
-
-		elevation.Angle = angleSum / float32(n)
 	}
 
 	scans := []Scan{}
@@ -146,32 +182,17 @@ func NexradToScans(l2Radar *nexrad.Nexrad) []Scan {
 	for _, e := range elevations {
 		for k, moment := range e.Moments {
 
-			gates := [][]float32{}
-
-			for _, m := range moment.Blocks {
-				tempGates := []float32{}
-
-				mask := 0
-				for _, g := range m.Gates {
-					if len(tempGates) > 0 && g == tempGates[len(tempGates)-1] {
-						mask++
-					} else {
-						if mask > 0 {
-							tempGates = append(tempGates, float32(-1000-mask))
-							mask = 0
-						} else {
-							tempGates = append(tempGates, g)
-						}
-					}
-				}
-
-				gates = append(gates, tempGates)
+			gates := make([]level2.Gates, len(moment.Blocks))
+			for i, m := range moment.Blocks {
+				gates[i] = m.Gates
 			}
 
 			scans = append(scans, Scan{
-				ICAO:               l2Radar.ICAO,
+				ICAO:               v.ICAO,
 				ProductType:        k,
 				ElevationNumber:    e.Number,
+				Supplemental:       e.Supplemental,
+				BaseElevation:      e.Base,
 				ElevationAngle:     e.Angle,
 				StartAzimuth:       moment.Blocks[0].AzimuthAngle,
 				StartAzimuthNumber: moment.Blocks[0].AzimuthNumber,
@@ -180,7 +201,7 @@ func NexradToScans(l2Radar *nexrad.Nexrad) []Scan {
 				GateInterval:       moment.GateInterval,
 				Lat:                e.Lat,
 				Lon:                e.Lon,
-				Gates:              &gates,
+				Gates:              gates,
 				InitTime:           time.Now(),
 				EOE:                eoe,
 				EOV:                eov,
@@ -196,6 +217,80 @@ func NexradToScans(l2Radar *nexrad.Nexrad) []Scan {
 	return scans
 }
 
+// Gate values sent in place of the reserved codes as NaN cannot be encoded to JSON
+const (
+	BelowThresholdGate float32 = -999
+	RangeFoldedGate    float32 = -998
+)
+
+func GatesFromMoment(m level2.Gates) []float32 {
+	gates := make([]float32, m.Len())
+	m.Each(func(i int, value float32, mask level2.GateMask) {
+		switch mask {
+		case level2.GateBelowThreshold:
+			gates[i] = BelowThresholdGate
+		case level2.GateRangeFolded:
+			gates[i] = RangeFoldedGate
+		default:
+			gates[i] = value
+		}
+	})
+	return gates
+}
+
+// Elevation angles of the sweeps seen so far in a volume
+type volumeAngleSet struct {
+	angles   map[int]float32
+	lastSeen time.Time
+}
+
+// How long the angles of a volume are kept after its last chunk, in case its EOV chunk never arrives
+const VolumeAnglesMaxAge = 30 * time.Minute
+
+var angleLock = &sync.Mutex{}
+
+// By volume ID
+var volumeAngles = map[string]*volumeAngleSet{}
+
+/*
+TagSupplementalSweeps marks the sweeps of a chunk that repeat a lower tilt. A chunk only holds part of
+a volume so the sweeps are checked against those of the earlier chunks
+*/
+func TagSupplementalSweeps(volumeID string, v *level2.Volume) {
+	angleLock.Lock()
+	defer angleLock.Unlock()
+
+	now := time.Now()
+	for id, set := range volumeAngles {
+		if now.Sub(set.lastSeen) > VolumeAnglesMaxAge {
+			delete(volumeAngles, id)
+		}
+	}
+
+	set, ok := volumeAngles[volumeID]
+	if !ok {
+		set = &volumeAngleSet{angles: map[int]float32{}}
+		volumeAngles[volumeID] = set
+	}
+	set.lastSeen = now
+
+	for _, sweep := range v.Sweeps {
+		if _, ok := set.angles[sweep.Number]; !ok {
+			set.angles[sweep.Number] = sweep.ElevationAngle
+		}
+		sweep.Base = level2.SupplementalBase(sweep.Number, set.angles[sweep.Number], set.angles)
+		sweep.Supplemental = sweep.Base != 0
+	}
+}
+
+// ForgetVolumeAngles drops the sweep angles kept for the volume once it is complete
+func ForgetVolumeAngles(volumeID string) {
+	angleLock.Lock()
+	defer angleLock.Unlock()
+
+	delete(volumeAngles, volumeID)
+}
+
 /*
 Finds the given scan in the slice of the scans. Returns the index. If the scan cannot be found, index is -1
 */
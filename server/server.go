@@ -1,4 +1,4 @@
-package main
+package server
 
 import (
 	"context"
@@ -11,9 +11,8 @@ import (
 	"github.com/joho/godotenv"
 )
 
-func main() {
-	Init(true)
-
+// Serve ingests the real-time feed and uploads the scans it completes. It never returns
+func Serve() {
 	scanChan := make(chan Scan)
 
 	go Ingest(scanChan)
@@ -1,4 +1,4 @@
-package main
+package server
 
 import (
 	"fmt"
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/TheRangiCrew/NEXRAD-GO/level2"
 	"github.com/surrealdb/surrealdb.go"
 	"github.com/surrealdb/surrealdb.go/pkg/conn/gorilla"
 	"github.com/surrealdb/surrealdb.go/pkg/marshal"
@@ -91,7 +92,7 @@ func GetSite(icao string) (*Site, error) {
 	}
 }
 
-func AddSite(icao string) (*Site, error) {
+func AddSite(icao string, location *level2.Site) (*Site, error) {
 
 	site := Site{
 		ID:        icao,
@@ -103,6 +104,15 @@ func AddSite(icao string) (*Site, error) {
 		Elevation: 0,
 	}
 
+	if location != nil {
+		site.Name = location.Name
+		site.Elevation = int(location.Height)
+		site.Location = Point{
+			Type:        "Point",
+			Coordinates: []float64{location.Lon, location.Lat},
+		}
+	}
+
 	data, err := surreal.Create("radar_site", site)
 	if err != nil {
 		return nil, err
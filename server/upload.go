@@ -1,4 +1,4 @@
-package main
+package server
 
 import (
 	"bytes"
@@ -6,17 +6,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// Uploads that have not finished yet
+var uploads sync.WaitGroup
+
+// Upload uploads each scan sent on scanChan. Once it is closed, Upload returns when the last upload is done
 func Upload(scanChan chan Scan) {
 
 	for scan := range scanChan {
-		go push(scan)
+		uploads.Add(1)
+		go func(scan Scan) {
+			defer uploads.Done()
+			push(scan)
+		}(scan)
 	}
+	uploads.Wait()
 }
 
 func push(scan Scan) {
@@ -37,6 +47,10 @@ func push(scan Scan) {
 	second := PadZero(strconv.Itoa(t.Second()), 2)
 
 	key := year + "/" + month + "/" + day + "/" + scan.ICAO + "/" + hour + "-" + minute + "-" + second + "-" + scan.ProductType + "-" + strconv.Itoa(scan.ElevationNumber)
+	// Supplemental scans are kept apart from the base tilt so clients can pick up the low level update
+	if scan.Supplemental {
+		key += "-supplemental"
+	}
 
 	// Create an io.Reader from the JSON data
 	reader := bytes.NewReader(jsonData)
@@ -1,4 +1,4 @@
-package main
+package server
 
 func PadZero(str string, length int) string {
 	for len(str) < length {